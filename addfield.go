@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// addFields sets each "pointer=value" assignment in turn. The value is
+// read as JSON when it parses and as a plain string otherwise, so that
+// /_source=file.json needs no extra quoting.
+func addFields(data interface{}, assignments []string) (interface{}, error) {
+	for _, assignment := range assignments {
+		pointer, text, ok := strings.Cut(assignment, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --add-field %q (want <pointer>=<value>)", assignment)
+		}
+		path, err := parsePointer(pointer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --add-field %q: %v", assignment, err)
+		}
+		if len(path) == 0 {
+			return nil, fmt.Errorf("invalid --add-field %q: the path must be below the document root", assignment)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			value = text
+		}
+		if data, err = addField(data, path, value); err != nil {
+			return nil, fmt.Errorf("--add-field %s: %v", pointer, err)
+		}
+	}
+	return data, nil
+}
+
+// addField sets the value at path, replacing any value already there and
+// creating objects for missing members along the way. The index "-"
+// appends to an array. Containers on the path are copied, not modified.
+func addField(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok && len(path) > 1 {
+			child = map[string]interface{}{}
+		}
+		updated, err := addField(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		copied := make(map[string]interface{}, len(n)+1)
+		for key, member := range n {
+			copied[key] = member
+		}
+		copied[path[0]] = updated
+		return copied, nil
+	case []interface{}:
+		index := len(n)
+		if path[0] != "-" || len(path) > 1 {
+			var err error
+			if index, err = arrayIndex(path[0], len(n)); err != nil {
+				return nil, err
+			}
+		}
+		copied := make([]interface{}, len(n), len(n)+1)
+		copy(copied, n)
+		if index == len(n) {
+			copied = append(copied, nil)
+		}
+		updated, err := addField(copied[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		copied[index] = updated
+		return copied, nil
+	default:
+		return nil, fmt.Errorf("cannot add %q to %s", path[0], jsonType(node))
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestAddFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		fields   []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "top-level field",
+			input:    `{"id": 1}`,
+			fields:   []string{"/_source=file.json"},
+			expected: `{"_source":"file.json","id":1}`,
+		},
+		{
+			name:     "nested field creates intermediate objects",
+			input:    `{"id": 1}`,
+			fields:   []string{"/meta/run/id=42"},
+			expected: `{"id":1,"meta":{"run":{"id":42}}}`,
+		},
+		{
+			name:     "nested field in an existing object",
+			input:    `{"meta": {"owner": "ops"}}`,
+			fields:   []string{"/meta/batch=7"},
+			expected: `{"meta":{"batch":7,"owner":"ops"}}`,
+		},
+		{
+			name:     "existing value replaced",
+			input:    `{"v": 1}`,
+			fields:   []string{"/v=2"},
+			expected: `{"v":2}`,
+		},
+		{
+			name:     "JSON values",
+			input:    `{}`,
+			fields:   []string{`/o={"a": [true]}`, "/n=null", `/s="7"`},
+			expected: `{"n":null,"o":{"a":[true]},"s":"7"}`,
+		},
+		{
+			name:     "value with an equals sign",
+			input:    `{}`,
+			fields:   []string{"/q=a=b"},
+			expected: `{"q":"a=b"}`,
+		},
+		{
+			name:     "escaped pointer tokens",
+			input:    `{}`,
+			fields:   []string{"/a~1b/c~0d=1"},
+			expected: `{"a/b":{"c~d":1}}`,
+		},
+		{
+			name:     "array element and append",
+			input:    `{"rows": [{"n": 1}]}`,
+			fields:   []string{"/rows/0/m=2", "/rows/-=3"},
+			expected: `{"rows":[{"m":2,"n":1},3]}`,
+		},
+		{name: "missing value", input: `{}`, fields: []string{"/x"}, wantErr: true},
+		{name: "pointer without slash", input: `{}`, fields: []string{"x=1"}, wantErr: true},
+		{name: "document root", input: `{}`, fields: []string{"=1"}, wantErr: true},
+		{name: "below a scalar", input: `{"a": "s"}`, fields: []string{"/a/b=1"}, wantErr: true},
+		{name: "below null", input: `{"a": null}`, fields: []string{"/a/b=1"}, wantErr: true},
+		{name: "array index out of range", input: `[]`, fields: []string{"/3=1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			result, err := addFields(data, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("addFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			output, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("addFields() = %s, want %s", output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAddFieldsLeavesInputUnchanged(t *testing.T) {
+	data, err := parseJSON(`{"meta": {"a": 1}, "rows": [1]}`)
+	if err != nil {
+		t.Fatalf("parseJSON() error = %v", err)
+	}
+	if _, err := addFields(data, []string{"/meta/b=2", "/rows/-=2"}); err != nil {
+		t.Fatalf("addFields() error = %v", err)
+	}
+	want, _ := parseJSON(`{"meta": {"a": 1}, "rows": [1]}`)
+	if !equalJSON(data, want) {
+		t.Errorf("addFields() modified its input: %v", data)
+	}
+}
+
+func TestRunAddField(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"--root-key", "items", "--add-field", "/_source=file.json", "encode", `[1, 2]`}
+	if code := run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if expected := `"{\"_source\":\"file.json\",\"items\":[1,2]}"` + "\n"; stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+}
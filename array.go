@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+// arrayAt resolves a JSON Pointer within a document and requires the
+// referenced value to be an array
+func arrayAt(doc interface{}, pointer string) ([]interface{}, error) {
+	value, err := resolvePointer(doc, pointer)
+	if err != nil {
+		return nil, err
+	}
+	array, ok := value.([]interface{})
+	if !ok {
+		if pointer == "" {
+			return nil, fmt.Errorf("expected an array at the document root, got %s", jsonType(value))
+		}
+		return nil, fmt.Errorf("expected an array at %q, got %s", pointer, jsonType(value))
+	}
+	return array, nil
+}
+
+// selectFirst returns the first n elements of an array, clamping n to the
+// array bounds so that negative or over-large counts never error
+func selectFirst(array []interface{}, n int) []interface{} {
+	return array[:clamp(n, 0, len(array))]
+}
+
+// selectLast returns the last n elements of an array, clamping n to the
+// array bounds so that negative or over-large counts never error
+func selectLast(array []interface{}, n int) []interface{} {
+	return array[len(array)-clamp(n, 0, len(array)):]
+}
+
+// shuffleArray returns a random permutation of an array drawn from rng,
+// leaving the input unchanged
+func shuffleArray(array []interface{}) []interface{} {
+	shuffled := append([]interface{}{}, array...)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+func clamp(n, low, high int) int {
+	if n < low {
+		return low
+	}
+	if n > high {
+		return high
+	}
+	return n
+}
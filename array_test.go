@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSelectFirstAndLast(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		first int
+		last  int
+		head  string
+		tail  string
+	}{
+		{name: "within bounds", input: `[1, 2, 3, 4, 5]`, first: 2, last: 2, head: `[1,2]`, tail: `[4,5]`},
+		{name: "whole array", input: `[1, 2, 3]`, first: 3, last: 3, head: `[1,2,3]`, tail: `[1,2,3]`},
+		{name: "over-large clamps", input: `[1, 2, 3]`, first: 10, last: 10, head: `[1,2,3]`, tail: `[1,2,3]`},
+		{name: "negative clamps", input: `[1, 2, 3]`, first: -1, last: -5, head: `[]`, tail: `[]`},
+		{name: "zero", input: `[1, 2, 3]`, first: 0, last: 0, head: `[]`, tail: `[]`},
+		{name: "empty array", input: `[]`, first: 2, last: 2, head: `[]`, tail: `[]`},
+		{name: "single element", input: `["only"]`, first: 1, last: 5, head: `["only"]`, tail: `["only"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			array, err := arrayAt(data, "")
+			if err != nil {
+				t.Fatalf("arrayAt() error = %v", err)
+			}
+
+			head, _ := json.Marshal(selectFirst(array, tt.first))
+			if string(head) != tt.head {
+				t.Errorf("selectFirst() = %s, want %s", head, tt.head)
+			}
+			tail, _ := json.Marshal(selectLast(array, tt.last))
+			if string(tail) != tt.tail {
+				t.Errorf("selectLast() = %s, want %s", tail, tt.tail)
+			}
+		})
+	}
+}
+
+func TestArrayAt(t *testing.T) {
+	data, err := parseJSON(`{"data": {"items": ["a", "b", "c"]}, "name": "list"}`)
+	if err != nil {
+		t.Fatalf("parseJSON() error = %v", err)
+	}
+
+	array, err := arrayAt(data, "/data/items")
+	if err != nil {
+		t.Fatalf("arrayAt() error = %v", err)
+	}
+	if got, _ := json.Marshal(selectLast(array, 2)); string(got) != `["b","c"]` {
+		t.Errorf("selectLast() = %s, want %s", got, `["b","c"]`)
+	}
+
+	if _, err := arrayAt(data, "/name"); err == nil {
+		t.Errorf("arrayAt() expected error for non-array target")
+	}
+	if _, err := arrayAt(data, ""); err == nil {
+		t.Errorf("arrayAt() expected error for object root")
+	}
+}
+
+func TestShuffleArray(t *testing.T) {
+	input := []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0}
+
+	seedRandom(1)
+	shuffled, _ := json.Marshal(shuffleArray(input))
+	if expected := `[3,8,2,6,7,5,1,4]`; string(shuffled) != expected {
+		t.Errorf("shuffleArray() with seed 1 = %s, want %s", shuffled, expected)
+	}
+	if original, _ := json.Marshal(input); string(original) != `[1,2,3,4,5,6,7,8]` {
+		t.Errorf("shuffleArray() modified its input to %s", original)
+	}
+}
+
+func TestRunShuffle(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:   "seeded permutation",
+			args:   []string{"--seed", "1", "shuffle", `[1, 2, 3, 4, 5, 6, 7, 8]`},
+			stdout: "[3,8,2,6,7,5,1,4]\n",
+		},
+		{
+			name:   "different seed",
+			args:   []string{"--seed", "2", "shuffle", `[1, 2, 3, 4, 5, 6, 7, 8]`},
+			stdout: "[5,1,4,7,2,3,6,8]\n",
+		},
+		{
+			name:   "array at path",
+			args:   []string{"--seed", "7", "--path", "/a", "shuffle", `{"a": ["x", "y", "z"]}`},
+			stdout: "[\"y\",\"z\",\"x\"]\n",
+		},
+		{
+			name:   "empty array",
+			args:   []string{"shuffle", `[]`},
+			stdout: "[]\n",
+		},
+		{
+			name:     "not an array",
+			args:     []string{"shuffle", `{"a": 1}`},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// exitAssertionFailed is the exit code used when an --assert-* predicate
+// does not hold, so that scripts can tell it apart from invalid input
+const exitAssertionFailed = 3
+
+// jsonTypes lists the type names accepted by --assert-type
+var jsonTypes = []string{"object", "array", "string", "number", "boolean", "null"}
+
+// assertOptions holds the predicates selected on the command line
+type assertOptions struct {
+	nonEmpty bool
+	typeName string
+}
+
+func (a assertOptions) enabled() bool {
+	return a.nonEmpty || a.typeName != ""
+}
+
+// validate checks the predicate settings before any input is processed
+func (a assertOptions) validate() error {
+	if a.typeName != "" && !isJSONType(a.typeName) {
+		return fmt.Errorf("unknown --assert-type %q (want %s)", a.typeName, strings.Join(jsonTypes, ", "))
+	}
+	return nil
+}
+
+// checkAssertions reports the first predicate that value does not satisfy
+func checkAssertions(value interface{}, opts assertOptions) error {
+	if opts.typeName != "" {
+		if got := jsonType(value); got != opts.typeName {
+			return fmt.Errorf("value is %s, want %s", withArticle(got), opts.typeName)
+		}
+	}
+	if opts.nonEmpty && isEmpty(value) {
+		return fmt.Errorf("value is empty")
+	}
+	return nil
+}
+
+func isJSONType(name string) bool {
+	for _, t := range jsonTypes {
+		if name == t {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmpty reports whether value is an empty object, array or string
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case string:
+		return v == ""
+	default:
+		return false
+	}
+}
+
+func withArticle(typeName string) string {
+	if strings.IndexByte("aeiou", typeName[0]) >= 0 {
+		return "an " + typeName
+	}
+	return "a " + typeName
+}
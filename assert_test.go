@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCheckAssertions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    assertOptions
+		input   interface{}
+		wantErr bool
+	}{
+		{name: "empty object", opts: assertOptions{nonEmpty: true}, input: map[string]interface{}{}, wantErr: true},
+		{name: "empty array", opts: assertOptions{nonEmpty: true}, input: []interface{}{}, wantErr: true},
+		{name: "empty string", opts: assertOptions{nonEmpty: true}, input: "", wantErr: true},
+		{name: "non-empty object", opts: assertOptions{nonEmpty: true}, input: map[string]interface{}{"a": nil}},
+		{name: "non-empty array", opts: assertOptions{nonEmpty: true}, input: []interface{}{nil}},
+		{name: "zero is not empty", opts: assertOptions{nonEmpty: true}, input: 0.0},
+		{name: "null is not empty", opts: assertOptions{nonEmpty: true}, input: nil},
+		{name: "type matches", opts: assertOptions{typeName: "object"}, input: map[string]interface{}{}},
+		{name: "number type matches", opts: assertOptions{typeName: "number"}, input: 1.5},
+		{name: "null type matches", opts: assertOptions{typeName: "null"}, input: nil},
+		{name: "type does not match", opts: assertOptions{typeName: "object"}, input: []interface{}{}, wantErr: true},
+		{name: "both hold", opts: assertOptions{nonEmpty: true, typeName: "array"}, input: []interface{}{1.0}},
+		{name: "type holds but empty", opts: assertOptions{nonEmpty: true, typeName: "array"}, input: []interface{}{}, wantErr: true},
+		{name: "no predicates", input: []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAssertions(tt.input, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkAssertions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssertOptionsValidate(t *testing.T) {
+	for _, typeName := range append(jsonTypes, "") {
+		if err := (assertOptions{typeName: typeName}).validate(); err != nil {
+			t.Errorf("validate(%q) error = %v", typeName, err)
+		}
+	}
+	if err := (assertOptions{typeName: "integer"}).validate(); err == nil {
+		t.Error("validate(\"integer\") expected error")
+	}
+}
+
+func TestRunAssertions(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:     "encode non-empty",
+			args:     []string{"--assert-nonempty", "encode", `{"a":1}`},
+			exitCode: 0,
+			stdout:   "\"{\\\"a\\\":1}\"\n",
+		},
+		{
+			name:     "encode empty",
+			args:     []string{"--assert-nonempty", "encode", `{}`},
+			exitCode: exitAssertionFailed,
+		},
+		{
+			name:     "decode type mismatch",
+			args:     []string{"--assert-type", "object", "decode", `"[1]"`},
+			exitCode: exitAssertionFailed,
+		},
+		{
+			name:     "array after slicing is empty",
+			args:     []string{"--assert-nonempty", "--first", "0", "array", `[1, 2]`},
+			exitCode: exitAssertionFailed,
+		},
+		{
+			name:     "unknown type",
+			args:     []string{"--assert-type", "list", "encode", `[]`},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
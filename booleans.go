@@ -0,0 +1,42 @@
+package main
+
+import "strings"
+
+// booleanStrings maps the strings --coerce-booleans recognizes, compared
+// without regard to case, to the booleans they stand for
+var booleanStrings = map[string]bool{
+	"true": true, "yes": true, "on": true, "1": true,
+	"false": false, "no": false, "off": false, "0": false,
+}
+
+// coerceBooleans replaces string values that spell a boolean with the
+// boolean. When keys is non-empty only the values of object members with
+// one of those names, including strings in arrays below them, are
+// considered.
+func coerceBooleans(value interface{}, keys map[string]bool) interface{} {
+	return coerceBooleanValue(value, keys, len(keys) == 0)
+}
+
+func coerceBooleanValue(value interface{}, keys map[string]bool, eligible bool) interface{} {
+	switch v := value.(type) {
+	case string:
+		if b, ok := booleanStrings[strings.ToLower(v)]; ok && eligible {
+			return b
+		}
+		return v
+	case map[string]interface{}:
+		coerced := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			coerced[key] = coerceBooleanValue(child, keys, len(keys) == 0 || keys[key])
+		}
+		return coerced
+	case []interface{}:
+		coerced := make([]interface{}, len(v))
+		for i, child := range v {
+			coerced[i] = coerceBooleanValue(child, keys, eligible)
+		}
+		return coerced
+	default:
+		return value
+	}
+}
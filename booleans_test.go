@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCoerceBooleans(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		keys     string
+		expected string
+	}{
+		{name: "true", input: `"true"`, expected: `true`},
+		{name: "yes", input: `"yes"`, expected: `true`},
+		{name: "on", input: `"on"`, expected: `true`},
+		{name: "1", input: `"1"`, expected: `true`},
+		{name: "false", input: `"false"`, expected: `false`},
+		{name: "no", input: `"no"`, expected: `false`},
+		{name: "off", input: `"off"`, expected: `false`},
+		{name: "0", input: `"0"`, expected: `false`},
+		{name: "any case", input: `["YES", "Off", "True"]`, expected: `[true,false,true]`},
+		{name: "unrecognized string", input: `["y", "enabled", " yes", "01", ""]`, expected: `["y","enabled"," yes","01",""]`},
+		{name: "numbers and booleans untouched", input: `[1, 0, true]`, expected: `[1,0,true]`},
+		{
+			name:     "limited to keys",
+			input:    `{"active": "yes", "flags": ["on", "off"], "answer": "no", "nested": {"active": "0"}}`,
+			keys:     "active,flags",
+			expected: `{"active":true,"answer":"no","flags":[true,false],"nested":{"active":false}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input interface{}
+			if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			result, err := json.Marshal(coerceBooleans(input, parseKeyList(tt.keys)))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("coerceBooleans() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
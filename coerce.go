@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// documentCommands lists the commands whose input is a JSON document, to
+// which --coerce-input applies
+var documentCommands = map[string]bool{
+	"encode":          true,
+	"array":           true,
+	"shuffle":         true,
+	"group-by":        true,
+	"split-by":        true,
+	"stats":           true,
+	"tondjson":        true,
+	"project":         true,
+	"dump":            true,
+	"pretty":          true,
+	"conform":         true,
+	"exists":          true,
+	"apply-patch":     true,
+	"apply-jsonpatch": true,
+	"gen-patch":       true,
+	"totoml":          true,
+	"toxml":           true,
+}
+
+// coerceInput turns input that is not valid JSON into a JSON string literal
+// holding it, so that bare text like hello is read as "hello". Input that
+// starts like an object or array, or is not valid UTF-8, is returned
+// unchanged: it is broken JSON rather than text and should still fail.
+func coerceInput(input string) (string, bool) {
+	if json.Valid([]byte(input)) || !utf8.ValidString(input) {
+		return input, false
+	}
+	if trimmed := strings.TrimSpace(input); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return input, false
+	}
+	quoted, err := json.Marshal(input)
+	if err != nil {
+		return input, false
+	}
+	return string(quoted), true
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCoerceInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		coerced  bool
+	}{
+		{name: "bare word", input: "hello", expected: `"hello"`, coerced: true},
+		{name: "text with quotes and newline", input: "say \"hi\"\nbye", expected: `"say \"hi\"\nbye"`, coerced: true},
+		{name: "valid JSON number unchanged", input: "42", expected: "42"},
+		{name: "valid JSON string unchanged", input: `"quoted"`, expected: `"quoted"`},
+		{name: "valid JSON object unchanged", input: `{"a": 1}`, expected: `{"a": 1}`},
+		{name: "broken object not coerced", input: `{"a": 1`, expected: `{"a": 1`},
+		{name: "broken array not coerced", input: `  [1, 2,`, expected: `  [1, 2,`},
+		{name: "invalid UTF-8 not coerced", input: "bad \xff", expected: "bad \xff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, coerced := coerceInput(tt.input)
+			if result != tt.expected || coerced != tt.coerced {
+				t.Errorf("coerceInput() = %q, %v, want %q, %v", result, coerced, tt.expected, tt.coerced)
+			}
+		})
+	}
+}
+
+func TestRunCoerceInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:   "bare text falls back to a string",
+			args:   []string{"--coerce-input", "encode", "hello world"},
+			stdout: "\"\\\"hello world\\\"\"\n",
+		},
+		{
+			name:   "valid JSON unaffected",
+			args:   []string{"--coerce-input", "array", `[1]`},
+			stdout: "[1]\n",
+		},
+		{
+			name:     "broken JSON still fails",
+			args:     []string{"--coerce-input", "encode", `{"key": "value"`},
+			exitCode: 1,
+		},
+		{
+			name:     "bare text fails without the flag",
+			args:     []string{"encode", "hello world"},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix starts the name of every environment variable read for flag
+// defaults, e.g. JSONENCODER_MAX_ARRAY_LENGTH for --max-array-length
+const envPrefix = "JSONENCODER_"
+
+// minVersionKey names the config entry holding the oldest jsonencoder
+// version the config is meant for
+const minVersionKey = "minVersion"
+
+// applyDefaults fills in flags that were not given on the command line,
+// first from the --config file and then from the environment, so that
+// the precedence is defaults < config < environment < flags
+func applyDefaults(fs *flag.FlagSet, configFile string, explicit map[string]bool) error {
+	if configFile == "" && !explicit["config"] {
+		configFile = os.Getenv(envName("config"))
+	}
+	if configFile != "" {
+		settings, err := loadConfig(configFile)
+		if err != nil {
+			return err
+		}
+		// Checked first, as a config for a newer version may use options
+		// this one does not know
+		if required, ok := settings[minVersionKey]; ok {
+			requiredStr, isString := required.(string)
+			if !isString {
+				return fmt.Errorf("%s: %s must be a string", configFile, minVersionKey)
+			}
+			if err := checkMinVersion(requiredStr, currentVersion()); err != nil {
+				return fmt.Errorf("%s: %v", configFile, err)
+			}
+			delete(settings, minVersionKey)
+		}
+		for _, name := range sortedKeys(settings) {
+			if name == "config" || fs.Lookup(name) == nil {
+				return fmt.Errorf("%s: unknown option %q", configFile, name)
+			}
+			if explicit[name] {
+				continue
+			}
+			if err := setFlag(fs, name, settings[name]); err != nil {
+				return fmt.Errorf("%s: %v", configFile, err)
+			}
+		}
+	}
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || explicit[f.Name] || f.Name == "config" || err != nil {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid %s: %v", envName(f.Name), setErr)
+		}
+	})
+	return err
+}
+
+// envName returns the environment variable consulted for a flag
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// loadConfig reads a config file mapping flag names to values. Files ending
+// in .yaml or .yml are read as YAML, anything else as JSON.
+func loadConfig(filename string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %v", err)
+	}
+
+	settings := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &settings)
+	default:
+		err = json.Unmarshal(content, &settings)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config %s: %v", filename, err)
+	}
+	return settings, nil
+}
+
+// setFlag assigns a config value to the flag of the same name
+func setFlag(fs *flag.FlagSet, name string, value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case bool:
+		text = strconv.FormatBool(v)
+	case int:
+		text = strconv.Itoa(v)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("option %q must be a string, number or boolean", name)
+	}
+
+	if err := fs.Set(name, text); err != nil {
+		return fmt.Errorf("invalid value for %q: %v", name, err)
+	}
+	return nil
+}
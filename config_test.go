@@ -0,0 +1,174 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig writes a config file with the given name to a temporary
+// directory and returns its path
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "json",
+			file:     "config.json",
+			content:  `{"trim-keys": true, "first": 2, "missing-key": "none"}`,
+			expected: map[string]interface{}{"trim-keys": true, "first": 2.0, "missing-key": "none"},
+		},
+		{
+			name:     "yaml",
+			file:     "config.yaml",
+			content:  "trim-keys: true\nfirst: 2\nmissing-key: none\n",
+			expected: map[string]interface{}{"trim-keys": true, "first": 2, "missing-key": "none"},
+		},
+		{
+			name:    "invalid json",
+			file:    "config.json",
+			content: `{"trim-keys": }`,
+			wantErr: true,
+		},
+		{
+			name:    "yaml that is not a mapping",
+			file:    "config.yml",
+			content: "- trim-keys\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := loadConfig(writeConfig(t, tt.file, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(settings) != len(tt.expected) {
+				t.Fatalf("loadConfig() = %v, want %v", settings, tt.expected)
+			}
+			for key, want := range tt.expected {
+				if settings[key] != want {
+					t.Errorf("loadConfig()[%q] = %v, want %v", key, settings[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	config := writeConfig(t, "config.json", `{"missing-key": "from-config", "first": 3, "trim-keys": true}`)
+
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		missingKey string
+		first      int
+		trimKeys   bool
+	}{
+		{
+			name:       "defaults",
+			args:       []string{"encode", "{}"},
+			missingKey: "_missing",
+		},
+		{
+			name:       "config overrides defaults",
+			args:       []string{"--config", config, "encode", "{}"},
+			missingKey: "from-config",
+			first:      3,
+			trimKeys:   true,
+		},
+		{
+			name:       "environment overrides config",
+			args:       []string{"--config", config, "encode", "{}"},
+			env:        map[string]string{"JSONENCODER_MISSING_KEY": "from-env", "JSONENCODER_TRIM_KEYS": "false"},
+			missingKey: "from-env",
+			first:      3,
+		},
+		{
+			name:       "flags override environment and config",
+			args:       []string{"--config", config, "--missing-key", "from-flag", "--first", "1", "encode", "{}"},
+			env:        map[string]string{"JSONENCODER_MISSING_KEY": "from-env"},
+			missingKey: "from-flag",
+			first:      1,
+			trimKeys:   true,
+		},
+		{
+			name:       "config named by environment",
+			args:       []string{"encode", "{}"},
+			env:        map[string]string{"JSONENCODER_CONFIG": config},
+			missingKey: "from-config",
+			first:      3,
+			trimKeys:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			var opts options
+			if _, _, err := parseArgs(tt.args, &opts, io.Discard); err != nil {
+				t.Fatalf("parseArgs() error = %v", err)
+			}
+			if opts.missingKey != tt.missingKey {
+				t.Errorf("missing key = %q, want %q", opts.missingKey, tt.missingKey)
+			}
+			if opts.first != tt.first {
+				t.Errorf("first = %d, want %d", opts.first, tt.first)
+			}
+			if opts.transforms.trimKeys != tt.trimKeys {
+				t.Errorf("trim keys = %v, want %v", opts.transforms.trimKeys, tt.trimKeys)
+			}
+			if tt.first != 0 && !opts.set["first"] {
+				t.Error("first not recorded as set")
+			}
+		})
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+	}{
+		{name: "unknown option", content: `{"bogus": 1}`},
+		{name: "invalid value", content: `{"first": "many"}`},
+		{name: "nested value", content: `{"path": ["a"]}`},
+		{name: "config inside config", content: `{"config": "other.json"}`},
+		{name: "invalid environment value", content: `{}`, env: map[string]string{"JSONENCODER_FIRST": "many"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			config := writeConfig(t, "config.json", tt.content)
+			var opts options
+			if _, _, err := parseArgs([]string{"--config", config, "encode", "{}"}, &opts, io.Discard); err == nil {
+				t.Error("parseArgs() expected error")
+			}
+		})
+	}
+}
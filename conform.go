@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// divergence is a place where a document's structure differs from a sample
+type divergence struct {
+	Path    string
+	Message string
+}
+
+func (d divergence) String() string {
+	return fmt.Sprintf("%q: %s", d.Path, d.Message)
+}
+
+// conform compares the structure of a document against a sample: objects
+// must have the same key sets and every value the same JSON type. Each
+// element of an array is compared with the first element of the sample's
+// array; an empty sample array accepts any elements.
+func conform(doc, sample interface{}) []divergence {
+	var found []divergence
+	compareStructure(doc, sample, nil, &found)
+	return found
+}
+
+func compareStructure(doc, sample interface{}, path []string, found *[]divergence) {
+	docType, sampleType := jsonType(doc), jsonType(sample)
+	if docType != sampleType {
+		*found = append(*found, divergence{
+			Path:    formatPointer(path),
+			Message: fmt.Sprintf("expected %s, found %s", sampleType, docType),
+		})
+		return
+	}
+
+	switch s := sample.(type) {
+	case map[string]interface{}:
+		d := doc.(map[string]interface{})
+		keys := make([]string, 0, len(s)+len(d))
+		for key := range s {
+			keys = append(keys, key)
+		}
+		for key := range d {
+			if _, ok := s[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			childPath := appendToken(path, key)
+			sampleValue, inSample := s[key]
+			docValue, inDoc := d[key]
+			switch {
+			case !inDoc:
+				*found = append(*found, divergence{
+					Path:    formatPointer(childPath),
+					Message: fmt.Sprintf("missing (sample has %s)", jsonType(sampleValue)),
+				})
+			case !inSample:
+				*found = append(*found, divergence{
+					Path:    formatPointer(childPath),
+					Message: "not in sample",
+				})
+			default:
+				compareStructure(docValue, sampleValue, childPath, found)
+			}
+		}
+	case []interface{}:
+		if len(s) == 0 {
+			return
+		}
+		for i, element := range doc.([]interface{}) {
+			compareStructure(element, s[0], appendToken(path, fmt.Sprint(i)), found)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConform(t *testing.T) {
+	sample := `{"name": "api", "port": 80, "tags": ["a"], "tls": {"on": true}, "any": []}`
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:  "conforming document",
+			input: `{"name": "web", "port": 8080, "tags": [], "tls": {"on": false}, "any": [1, "x"]}`,
+		},
+		{
+			name:  "diverging document",
+			input: `{"name": "web", "port": "80", "tags": ["b", 1], "tls": {}, "any": [], "extra": null}`,
+			expected: []string{
+				`"/extra": not in sample`,
+				`"/port": expected number, found string`,
+				`"/tags/1": expected string, found number`,
+				`"/tls/on": missing (sample has boolean)`,
+			},
+		},
+		{
+			name:     "different root type",
+			input:    `[1]`,
+			expected: []string{`"": expected object, found array`},
+		},
+	}
+
+	var parsedSample interface{}
+	if err := json.Unmarshal([]byte(sample), &parsedSample); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc interface{}
+			if err := json.Unmarshal([]byte(tt.input), &doc); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			var got []string
+			for _, d := range conform(doc, parsedSample) {
+				got = append(got, d.String())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("conform() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunConform(t *testing.T) {
+	sample := filepath.Join(t.TempDir(), "sample.json")
+	if err := os.WriteFile(sample, []byte(`{"id": 1, "tags": ["a"]}`), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:     "conforming",
+			args:     []string{"--sample", sample, "conform", `{"id": 2, "tags": []}`},
+			exitCode: 0,
+		},
+		{
+			name:     "diverging",
+			args:     []string{"--sample", sample, "conform", `{"id": "2", "tags": ["b"]}`},
+			exitCode: exitAssertionFailed,
+			stdout:   "\"/id\": expected number, found string\n",
+		},
+		{
+			name:     "missing sample flag",
+			args:     []string{"conform", `{}`},
+			exitCode: 1,
+		},
+		{
+			name:     "unreadable sample",
+			args:     []string{"--sample", sample + ".missing", "conform", `{}`},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isASCIIControl reports whether r is an ASCII control character
+func isASCIIControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// replaceControl replaces every ASCII control character in s with
+// replacement, which may be empty to remove them
+func replaceControl(s, replacement string) string {
+	if strings.IndexFunc(s, isASCIIControl) < 0 {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if isASCIIControl(r) {
+			b.WriteString(replacement)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// stripControl removes or replaces the ASCII control characters in every
+// string value, and in object keys as well when keys is set. Keys that
+// become identical are resolved according to strategy.
+func stripControl(value interface{}, replacement string, keys bool, strategy string) (interface{}, error) {
+	if strings.IndexFunc(replacement, isASCIIControl) >= 0 {
+		return nil, fmt.Errorf("invalid --replace-control %q: must not contain control characters", replacement)
+	}
+	value = stripControlValues(value, replacement)
+	if !keys {
+		return value, nil
+	}
+	if err := validateKeyCollision(strategy); err != nil {
+		return nil, err
+	}
+	return renameKeys(value, func(key string) string {
+		return replaceControl(key, replacement)
+	}, strategy, "after stripping control characters")
+}
+
+func stripControlValues(value interface{}, replacement string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return replaceControl(v, replacement)
+	case map[string]interface{}:
+		stripped := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			stripped[key] = stripControlValues(child, replacement)
+		}
+		return stripped
+	case []interface{}:
+		stripped := make([]interface{}, len(v))
+		for i, child := range v {
+			stripped[i] = stripControlValues(child, replacement)
+		}
+		return stripped
+	default:
+		return value
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestStripControl(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       interface{}
+		replacement string
+		keys        bool
+		strategy    string
+		expected    interface{}
+		wantErr     bool
+	}{
+		{
+			name:     "removes control characters",
+			input:    "bell\x07 esc\x1b[0m nul\x00 del\x7f\ttab\nline",
+			expected: "bell esc[0m nul deltabline",
+		},
+		{
+			name:        "replaces control characters",
+			input:       []interface{}{"a\x00b", "c\r\nd"},
+			replacement: " ",
+			expected:    []interface{}{"a b", "c  d"},
+		},
+		{
+			name:     "non-ASCII text kept",
+			input:    "café   ok",
+			expected: "café   ok",
+		},
+		{
+			name:     "keys untouched by default",
+			input:    map[string]interface{}{"k\x01": "v\x01", "n": 1.0},
+			expected: map[string]interface{}{"k\x01": "v", "n": 1.0},
+		},
+		{
+			name:        "keys included",
+			input:       map[string]interface{}{"k\x01": map[string]interface{}{"\tx": "v"}},
+			replacement: "_",
+			keys:        true,
+			expected:    map[string]interface{}{"k_": map[string]interface{}{"_x": "v"}},
+		},
+		{
+			name:     "colliding keys",
+			input:    map[string]interface{}{"k": 1.0, "k\x00": 2.0},
+			keys:     true,
+			strategy: "error",
+			wantErr:  true,
+		},
+		{
+			name:     "colliding keys keep last",
+			input:    map[string]interface{}{"k": 1.0, "k\x00": 2.0},
+			keys:     true,
+			strategy: "last",
+			expected: map[string]interface{}{"k": 2.0},
+		},
+		{
+			name:        "control character replacement",
+			input:       "a",
+			replacement: "\n",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := tt.strategy
+			if strategy == "" {
+				strategy = "error"
+			}
+			result, err := stripControl(tt.input, tt.replacement, tt.keys, strategy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("stripControl() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("stripControl() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunStripControl(t *testing.T) {
+	input := "{\"msg\": \"ok\\u0007\\u001b[1m\"}"
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--strip-control", "encode", input}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	expected := `"{\"msg\":\"ok[1m\"}"` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// determinismRuns is how many times each command is repeated. Go
+// randomizes map iteration, so any output that depends on it changes well
+// within this many runs.
+const determinismRuns = 50
+
+// wideDocument builds an object with enough members that map iteration
+// order differs between runs
+func wideDocument() string {
+	var members []string
+	for i := 0; i < 40; i++ {
+		members = append(members, fmt.Sprintf(`" ns:key%02d ": {"n": %d, "tags": [null, "", "yes", {"x": null}], "at": "2024-01-0%dT10:00:00+02:00"}`, i, i%7, i%9+1))
+	}
+	return "{" + strings.Join(members, ", ") + "}"
+}
+
+func TestRunDeterministic(t *testing.T) {
+	doc := wideDocument()
+	collide := `{"a:x": 1, "b:x": 2, "c:x": 3, "d:x": 4, "e:x": 5, "f:y": {"g:z": 6, "h:z": 7}}`
+	items := `[{"team": "a", "n": 3}, {"team": "b", "n": 1}, {"team": "a", "n": 2}, {"n": 5}, {"team": "c", "n": 4}]`
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "encode", args: []string{"encode", doc}},
+		{name: "key transforms", args: []string{"--trim-keys", "--strip-key-prefix", ":", "--unicode-normalize", "nfc", "--normalize-keys", "encode", doc}},
+		{name: "value transforms", args: []string{"--empty-policy", "empty-to-null", "--trim-all-nulls", "--coerce-booleans", "--normalize-timestamps", "rfc3339", "--null-to", "0", "encode", doc}},
+		{name: "clamp and pipeline", args: []string{"--clamp-depth", "2", "--pipeline", "drop-nulls", "encode", doc}},
+		{name: "sort by value", args: []string{"--sort-objects-by", "value", "encode", doc}},
+		{name: "collation", args: []string{"--collation", "sv", "encode", doc}},
+		{name: "collision first", args: []string{"--strip-key-prefix", ":", "--key-collision", "first", "encode", collide}},
+		{name: "collision last", args: []string{"--strip-key-prefix", ":", "--key-collision", "last", "encode", collide}},
+		{name: "collision error", args: []string{"--strip-key-prefix", ":", "encode", collide}},
+		{name: "reports", args: []string{"--histogram", "--find-duplicates", "--scan-secrets", "--report-dup-keys", "encode", doc}},
+		{name: "yaml input", args: []string{"--input-format", "yaml", "encode", "b: 1\na: 2\n3: c\ntrue: d\n"}},
+		{name: "group-by", args: []string{"--key", "/team", "group-by", items}},
+		{name: "stats", args: []string{"--path", "/n", "stats", items}},
+		{name: "dump", args: []string{"--kv", "dump", doc}},
+		{name: "totoml", args: []string{"totoml", doc}},
+		{name: "toxml", args: []string{"toxml", `{"root": {"b": 1, "a": [1, 2], "c": {"e": true, "d": null}}}`}},
+		{name: "gen-patch", args: []string{"gen-patch", doc, collide}},
+		{name: "gen-patch json", args: []string{"--diff-format", "json", "gen-patch", doc, collide}},
+		{name: "gen-patch text", args: []string{"--diff-format", "text", "gen-patch", collide, doc}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wantOut, wantErr bytes.Buffer
+			wantCode := run(tt.args, nil, &wantOut, &wantErr)
+
+			for i := 1; i < determinismRuns; i++ {
+				var stdout, stderr bytes.Buffer
+				code := run(tt.args, nil, &stdout, &stderr)
+				if code != wantCode {
+					t.Fatalf("run #%d = %d, want %d", i, code, wantCode)
+				}
+				if !bytes.Equal(stdout.Bytes(), wantOut.Bytes()) {
+					t.Fatalf("run #%d stdout = %q, want %q", i, stdout.String(), wantOut.String())
+				}
+				if !bytes.Equal(stderr.Bytes(), wantErr.Bytes()) {
+					t.Fatalf("run #%d stderr = %q, want %q", i, stderr.String(), wantErr.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// leaf is a scalar, or an empty object or array, with its JSON Pointer
+type leaf struct {
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// collectLeaves lists every leaf of a document in walk order. Empty objects
+// and arrays count as leaves so that they are not lost.
+func collectLeaves(doc interface{}) []leaf {
+	var leaves []leaf
+	walkJSON(doc, nil, func(path []string, value interface{}) error {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if len(v) > 0 {
+				return nil
+			}
+		case []interface{}:
+			if len(v) > 0 {
+				return nil
+			}
+		}
+		leaves = append(leaves, leaf{Path: formatPointer(path), Value: value})
+		return nil
+	})
+	return leaves
+}
+
+// dumpLeaves formats the leaves of a document one per line. By default each
+// line is a {"path": ..., "value": ...} object; with kv set it is
+// "path = value", using separator in place of "=" and the value as JSON.
+// The root's empty pointer is printed as "$" in kv lines, since "/" already
+// names the member with the empty key.
+func dumpLeaves(doc interface{}, kv bool, separator string) (string, error) {
+	leaves := collectLeaves(doc)
+	lines := make([]string, len(leaves))
+	for i, l := range leaves {
+		var line []byte
+		var err error
+		if kv {
+			line, err = json.Marshal(l.Value)
+			if err == nil {
+				path := l.Path
+				if path == "" {
+					path = "$"
+				}
+				line = []byte(fmt.Sprintf("%s %s %s", path, separator, line))
+			}
+		} else {
+			line, err = json.Marshal(l)
+		}
+		if err != nil {
+			return "", fmt.Errorf("value at %q: %v", l.Path, err)
+		}
+		lines[i] = string(line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDumpLeaves(t *testing.T) {
+	doc := `{"user": {"name": "Ann", "age": 30, "roles": ["admin", "dev"], "tags": []}, "ok": true, "note": null}`
+
+	tests := []struct {
+		name      string
+		input     string
+		kv        bool
+		separator string
+		expected  string
+	}{
+		{
+			name:  "json lines",
+			input: doc,
+			expected: `{"path":"/note","value":null}` + "\n" +
+				`{"path":"/ok","value":true}` + "\n" +
+				`{"path":"/user/age","value":30}` + "\n" +
+				`{"path":"/user/name","value":"Ann"}` + "\n" +
+				`{"path":"/user/roles/0","value":"admin"}` + "\n" +
+				`{"path":"/user/roles/1","value":"dev"}` + "\n" +
+				`{"path":"/user/tags","value":[]}`,
+		},
+		{
+			name:      "key value lines",
+			input:     doc,
+			kv:        true,
+			separator: "=",
+			expected: `/note = null` + "\n" +
+				`/ok = true` + "\n" +
+				`/user/age = 30` + "\n" +
+				`/user/name = "Ann"` + "\n" +
+				`/user/roles/0 = "admin"` + "\n" +
+				`/user/roles/1 = "dev"` + "\n" +
+				`/user/tags = []`,
+		},
+		{
+			name:      "custom separator and escaped keys",
+			input:     `{"a/b": {"c~d": "x y"}}`,
+			kv:        true,
+			separator: "->",
+			expected:  `/a~1b/c~0d -> "x y"`,
+		},
+		{
+			name:      "scalar root",
+			input:     `5`,
+			kv:        true,
+			separator: "=",
+			expected:  `$ = 5`,
+		},
+		{
+			name:      "empty root object",
+			input:     `{}`,
+			kv:        true,
+			separator: "=",
+			expected:  `$ = {}`,
+		},
+		{
+			name:      "empty key is not the root",
+			input:     `{"": 5}`,
+			kv:        true,
+			separator: "=",
+			expected:  `/ = 5`,
+		},
+		{
+			name:     "scalar root json lines",
+			input:    `5`,
+			expected: `{"path":"","value":5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			result, err := dumpLeaves(data, tt.kv, tt.separator)
+			if err != nil {
+				t.Fatalf("dumpLeaves() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("dumpLeaves() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// duplicateKeys lists the keys that occur more than once in one object
+type duplicateKeys struct {
+	Path   string
+	Keys   []string
+	Counts map[string]int
+}
+
+// findDuplicateKeys scans JSON text token by token and reports every object
+// that repeats a key, in document order. Decoding into a map would silently
+// keep only the last occurrence, so the raw tokens are inspected instead.
+func findDuplicateKeys(jsonStr string) ([]duplicateKeys, error) {
+	decoder := json.NewDecoder(strings.NewReader(jsonStr))
+	var found []*duplicateKeys
+	if err := scanDuplicateKeys(decoder, nil, &found); err != nil {
+		return nil, err
+	}
+
+	var results []duplicateKeys
+	for _, d := range found {
+		if len(d.Keys) > 0 {
+			results = append(results, *d)
+		}
+	}
+	return results, nil
+}
+
+func scanDuplicateKeys(decoder *json.Decoder, path []string, found *[]*duplicateKeys) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		// Reserve a slot now so that outer objects are listed before inner ones
+		entry := &duplicateKeys{Path: formatPointer(path), Counts: make(map[string]int)}
+		*found = append(*found, entry)
+
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key := keyToken.(string)
+			entry.Counts[key]++
+			if entry.Counts[key] == 2 {
+				entry.Keys = append(entry.Keys, key)
+			}
+			if err := scanDuplicateKeys(decoder, append(path, key), found); err != nil {
+				return err
+			}
+		}
+		for key, count := range entry.Counts {
+			if count < 2 {
+				delete(entry.Counts, key)
+			}
+		}
+		_, err = decoder.Token()
+		return err
+	case json.Delim('['):
+		for i := 0; decoder.More(); i++ {
+			if err := scanDuplicateKeys(decoder, append(path, strconv.Itoa(i)), found); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+		return err
+	}
+	return nil
+}
+
+// reportDuplicateKeys writes one line per object containing duplicate keys
+func reportDuplicateKeys(w io.Writer, jsonStr string) error {
+	duplicates, err := findDuplicateKeys(jsonStr)
+	if err != nil {
+		return err
+	}
+	for _, d := range duplicates {
+		keys := make([]string, len(d.Keys))
+		for i, key := range d.Keys {
+			keys[i] = fmt.Sprintf("%q (%d)", key, d.Counts[key])
+		}
+		fmt.Fprintf(w, "duplicate keys at %q: %s\n", d.Path, strings.Join(keys, ", "))
+	}
+	return nil
+}
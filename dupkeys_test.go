@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicateKeys(t *testing.T) {
+	input := `{
+		"a": 1,
+		"b": {"x": 1, "y": 2, "x": 3, "y": 4, "x": 5},
+		"a": 2,
+		"list": [{"k": 1}, {"k": 1, "k": 2}, [{"z": 0, "z": 0}]],
+		"c": {"unique": true}
+	}`
+
+	duplicates, err := findDuplicateKeys(input)
+	if err != nil {
+		t.Fatalf("findDuplicateKeys() error = %v", err)
+	}
+
+	expected := []duplicateKeys{
+		{Path: "", Keys: []string{"a"}, Counts: map[string]int{"a": 2}},
+		{Path: "/b", Keys: []string{"x", "y"}, Counts: map[string]int{"x": 3, "y": 2}},
+		{Path: "/list/1", Keys: []string{"k"}, Counts: map[string]int{"k": 2}},
+		{Path: "/list/2/0", Keys: []string{"z"}, Counts: map[string]int{"z": 2}},
+	}
+	if !reflect.DeepEqual(duplicates, expected) {
+		t.Errorf("findDuplicateKeys() = %+v, want %+v", duplicates, expected)
+	}
+}
+
+func TestFindDuplicateKeysNone(t *testing.T) {
+	duplicates, err := findDuplicateKeys(`{"a": {"a": 1}, "b": [{"a": 1}, {"a": 2}]}`)
+	if err != nil {
+		t.Fatalf("findDuplicateKeys() error = %v", err)
+	}
+	if len(duplicates) != 0 {
+		t.Errorf("findDuplicateKeys() = %+v, want none", duplicates)
+	}
+}
+
+func TestReportDuplicateKeys(t *testing.T) {
+	var buf bytes.Buffer
+	if err := reportDuplicateKeys(&buf, `{"a": 1, "a": 2, "b": [{"x": 1, "x": 2, "x": 3}]}`); err != nil {
+		t.Fatalf("reportDuplicateKeys() error = %v", err)
+	}
+	expected := "duplicate keys at \"\": \"a\" (2)\nduplicate keys at \"/b/0\": \"x\" (3)\n"
+	if buf.String() != expected {
+		t.Errorf("reportDuplicateKeys() = %q, want %q", buf.String(), expected)
+	}
+
+	if err := reportDuplicateKeys(&buf, `{"a": `); err == nil {
+		t.Errorf("reportDuplicateKeys() expected error for invalid JSON")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// duplicateStructure lists the places where one object or array occurs
+// more than once in a document
+type duplicateStructure struct {
+	Paths []string
+	Size  int // bytes of the minified JSON of one occurrence
+}
+
+// findDuplicateStructures groups the non-empty objects and arrays of a
+// document by a hash of their canonical JSON, with keys sorted, and returns
+// the groups with more than one occurrence. The parts of a duplicate are
+// duplicates too, so values inside an occurrence are not listed again.
+// Groups that would save the most bytes if deduplicated come first.
+func findDuplicateStructures(value interface{}) []duplicateStructure {
+	groups := make(map[[sha256.Size]byte]*duplicateStructure)
+	var order []*duplicateStructure
+	hashStructures(value, nil, func(path []string, sum [sha256.Size]byte, size int) {
+		group, ok := groups[sum]
+		if !ok {
+			group = &duplicateStructure{Size: size}
+			groups[sum] = group
+			order = append(order, group)
+		}
+		group.Paths = append(group.Paths, formatPointer(path))
+	})
+
+	duplicated := make(map[string]bool)
+	for _, group := range order {
+		if len(group.Paths) > 1 {
+			for _, path := range group.Paths {
+				duplicated[path] = true
+			}
+		}
+	}
+
+	var result []duplicateStructure
+	for _, group := range order {
+		if len(group.Paths) < 2 {
+			continue
+		}
+		var paths []string
+		for _, path := range group.Paths {
+			if !insideDuplicate(path, duplicated) {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) > 1 {
+			result = append(result, duplicateStructure{Paths: paths, Size: group.Size})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Size*(len(result[i].Paths)-1) > result[j].Size*(len(result[j].Paths)-1)
+	})
+	return result
+}
+
+// insideDuplicate reports whether a JSON Pointer lies inside one of the
+// duplicated values
+func insideDuplicate(path string, duplicated map[string]bool) bool {
+	for i := strings.LastIndexByte(path, '/'); i >= 0; i = strings.LastIndexByte(path, '/') {
+		path = path[:i]
+		if duplicated[path] {
+			return true
+		}
+	}
+	return false
+}
+
+// hashStructures calls fn with the path, hash and size of every non-empty
+// object and array, and returns the canonical JSON of value. Inner values
+// are reported before the values containing them.
+func hashStructures(value interface{}, path []string, fn func(path []string, sum [sha256.Size]byte, size int)) []byte {
+	var canonical []byte
+	switch v := value.(type) {
+	case map[string]interface{}:
+		canonical = append(canonical, '{')
+		for i, key := range sortedKeys(v) {
+			if i > 0 {
+				canonical = append(canonical, ',')
+			}
+			name, _ := json.Marshal(key)
+			canonical = append(canonical, name...)
+			canonical = append(canonical, ':')
+			canonical = append(canonical, hashStructures(v[key], append(path, key), fn)...)
+		}
+		canonical = append(canonical, '}')
+	case []interface{}:
+		canonical = append(canonical, '[')
+		for i, child := range v {
+			if i > 0 {
+				canonical = append(canonical, ',')
+			}
+			canonical = append(canonical, hashStructures(child, append(path, strconv.Itoa(i)), fn)...)
+		}
+		canonical = append(canonical, ']')
+	default:
+		canonical, _ = json.Marshal(value)
+		return canonical
+	}
+	if len(canonical) > 2 {
+		fn(path, sha256.Sum256(canonical), len(canonical))
+	}
+	return canonical
+}
+
+// reportDuplicateStructures writes one line per group of repeated objects
+// and arrays in a JSON document
+func reportDuplicateStructures(w io.Writer, jsonStr string) error {
+	data, err := parseJSON(jsonStr)
+	if err != nil {
+		return err
+	}
+	for _, group := range findDuplicateStructures(data) {
+		paths := make([]string, len(group.Paths))
+		for i, path := range group.Paths {
+			paths[i] = strconv.Quote(path)
+		}
+		fmt.Fprintf(w, "duplicate structure of %d bytes at %s\n", group.Size, strings.Join(paths, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicateStructures(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []duplicateStructure
+	}{
+		{
+			name:  "repeated object",
+			input: `{"billing": {"street": "1 Main St", "zip": "12345"}, "shipping": {"zip": "12345", "street": "1 Main St"}, "name": "a"}`,
+			expected: []duplicateStructure{
+				{Paths: []string{"/billing", "/shipping"}, Size: 36},
+			},
+		},
+		{
+			name:  "inner values of a duplicate not repeated",
+			input: `[{"tags": ["x", "y"]}, {"tags": ["x", "y"]}, {"other": ["x", "y"]}]`,
+			expected: []duplicateStructure{
+				{Paths: []string{"/0", "/1"}, Size: 18},
+			},
+		},
+		{
+			name:  "larger savings first",
+			input: `{"a": [1, 2], "b": [1, 2], "c": [1, 2], "d": {"k": "long value"}, "e": {"k": "long value"}}`,
+			expected: []duplicateStructure{
+				{Paths: []string{"/d", "/e"}, Size: 18},
+				{Paths: []string{"/a", "/b", "/c"}, Size: 5},
+			},
+		},
+		{name: "empty values ignored", input: `[{}, {}, [], []]`},
+		{name: "no duplicates", input: `{"a": {"x": 1}, "b": {"x": 2}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			if got := findDuplicateStructures(data); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("findDuplicateStructures() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReportDuplicateStructures(t *testing.T) {
+	var buf bytes.Buffer
+	if err := reportDuplicateStructures(&buf, `{"a": {"x": 1}, "b": {"x": 1}}`); err != nil {
+		t.Fatalf("reportDuplicateStructures() error = %v", err)
+	}
+	expected := "duplicate structure of 7 bytes at \"/a\", \"/b\"\n"
+	if buf.String() != expected {
+		t.Errorf("reportDuplicateStructures() = %q, want %q", buf.String(), expected)
+	}
+
+	if err := reportDuplicateStructures(&buf, `{"a":`); err == nil {
+		t.Error("reportDuplicateStructures() expected error for invalid JSON")
+	}
+}
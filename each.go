@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// streamArray decodes the elements of the array at pointer one at a time,
+// calling fn with each, so that only one element is held in memory. The
+// document is read only as far as the end of the array; anything after it
+// is not checked.
+func streamArray(r io.Reader, pointer string, fn func(index int, element interface{}) error) error {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(r)
+	for _, token := range tokens {
+		if err := seekChild(decoder, token); err != nil {
+			return fmt.Errorf("JSON pointer %q: %v", pointer, err)
+		}
+	}
+
+	start, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON input: %v", err)
+	}
+	if start != json.Delim('[') {
+		return fmt.Errorf("expected an array at %q, got %s", pointer, tokenType(start))
+	}
+	for i := 0; decoder.More(); i++ {
+		var element interface{}
+		if err := decoder.Decode(&element); err != nil {
+			return fmt.Errorf("element %d: invalid JSON input: %v", i, err)
+		}
+		if err := fn(i, element); err != nil {
+			return fmt.Errorf("element %d: %v", i, err)
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("invalid JSON input: %v", err)
+	}
+	return nil
+}
+
+// seekChild advances the decoder to the start of the member or element of
+// the next value named by a reference token, skipping the ones before it
+func seekChild(decoder *json.Decoder, token string) error {
+	start, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON input: %v", err)
+	}
+	switch start {
+	case json.Delim('{'):
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return fmt.Errorf("invalid JSON input: %v", err)
+			}
+			if key == token {
+				return nil
+			}
+			if err := skipValue(decoder); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("key %q not found", token)
+	case json.Delim('['):
+		index, err := arrayIndex(token, math.MaxInt)
+		if err != nil {
+			return err
+		}
+		for i := 0; decoder.More(); i++ {
+			if i == index {
+				return nil
+			}
+			if err := skipValue(decoder); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("array index %s out of range", token)
+	default:
+		return fmt.Errorf("cannot index into %s", tokenType(start))
+	}
+}
+
+// skipValue reads past the next value without keeping it
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("invalid JSON input: %v", err)
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// tokenType names the JSON type of the value a decoder token starts
+func tokenType(token json.Token) string {
+	switch token {
+	case json.Delim('{'):
+		return "object"
+	case json.Delim('['):
+		return "array"
+	}
+	return jsonType(token)
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStreamArray(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		pointer  string
+		expected []string
+		wantErr  string
+	}{
+		{
+			name:     "root array",
+			input:    `[1, "two", {"three": [3]}, null]`,
+			expected: []string{`1`, `"two"`, `{"three":[3]}`, `null`},
+		},
+		{
+			name:     "nested array after skipped siblings",
+			input:    `{"meta": {"skip": [1, {"a": [2]}]}, "data": {"n": 1, "items": [{"id": 1}, {"id": 2}]}, "after": "x"}`,
+			pointer:  "/data/items",
+			expected: []string{`{"id":1}`, `{"id":2}`},
+		},
+		{
+			name:     "array element of an array",
+			input:    `[[1], [2, 3]]`,
+			pointer:  "/1",
+			expected: []string{`2`, `3`},
+		},
+		{
+			name:    "empty array",
+			input:   `{"a": []}`,
+			pointer: "/a",
+		},
+		{name: "not an array", input: `{"a": {}}`, pointer: "/a", wantErr: `expected an array at "/a", got object`},
+		{name: "missing key", input: `{"a": []}`, pointer: "/b", wantErr: `key "b" not found`},
+		{name: "index out of range", input: `[[1]]`, pointer: "/1", wantErr: `array index 1 out of range`},
+		{name: "scalar", input: `{"a": 1}`, pointer: "/a/0", wantErr: `cannot index into number`},
+		{name: "invalid element", input: `[1, tru]`, wantErr: `element 1: invalid JSON input`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := streamArray(strings.NewReader(tt.input), tt.pointer, func(index int, element interface{}) error {
+				encoded, err := json.Marshal(element)
+				got = append(got, string(encoded))
+				return err
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("streamArray() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("streamArray() error = %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("streamArray() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// generatedArray produces a large JSON array of objects on demand, counting
+// the bytes handed out so far, without ever holding the whole document
+type generatedArray struct {
+	count, next int
+	pending     []byte
+	read        int
+}
+
+func (g *generatedArray) Read(p []byte) (int, error) {
+	for len(g.pending) == 0 {
+		switch {
+		case g.next > g.count:
+			return 0, io.EOF
+		case g.next == g.count:
+			g.pending = []byte("]")
+		case g.next == 0:
+			g.pending = []byte(fmt.Sprintf(`[{"id": %d, "pad": "%s"}`, g.next, strings.Repeat("x", 100)))
+		default:
+			g.pending = []byte(fmt.Sprintf(`, {"id": %d, "pad": "%s"}`, g.next, strings.Repeat("x", 100)))
+		}
+		g.next++
+	}
+	n := copy(p, g.pending)
+	g.pending = g.pending[n:]
+	g.read += n
+	return n, nil
+}
+
+func TestStreamArrayLargeInput(t *testing.T) {
+	const count = 100000
+	input := &generatedArray{count: count}
+
+	seen := 0
+	err := streamArray(input, "", func(index int, element interface{}) error {
+		id := element.(map[string]interface{})["id"].(float64)
+		if int(id) != index {
+			return fmt.Errorf("element %d has id %v", index, id)
+		}
+		// Elements are handed over while the input is still being read,
+		// with no more than the decoder's buffer read ahead
+		if index == 0 && input.read > 64*1024 {
+			return fmt.Errorf("read %d bytes before the first element", input.read)
+		}
+		seen++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("streamArray() error = %v", err)
+	}
+	if seen != count {
+		t.Errorf("streamArray() visited %d elements, want %d", seen, count)
+	}
+}
+
+func TestStreamArrayIncrementalOutput(t *testing.T) {
+	// The input breaks off after two elements; both are still delivered
+	failure := errors.New("connection reset")
+	input := io.MultiReader(strings.NewReader(`[{"id": 1}, {"id": 2}, {"id"`), iotest.ErrReader(failure))
+
+	var got []interface{}
+	err := streamArray(input, "", func(index int, element interface{}) error {
+		got = append(got, element)
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("streamArray() error = %v, want the read error", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("streamArray() delivered %d elements before failing, want 2", len(got))
+	}
+}
+
+func TestRunEach(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "records.json")
+	content := `{"records": [{"id": 1, "name": "a", "x": true}, {"id": 2, "name": "b"}]}`
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:   "tondjson from file",
+			args:   []string{"-f", "--each", "/records", "tondjson", filename},
+			stdout: "{\"id\":1,\"name\":\"a\",\"x\":true}\n{\"id\":2,\"name\":\"b\"}\n",
+		},
+		{
+			name:   "project each element",
+			args:   []string{"--each", "/records", "--fields", "/id,/x", "--omit-missing", "project", content},
+			stdout: "{\"id\":1,\"x\":true}\n{\"id\":2}\n",
+		},
+		{
+			name:     "partial output before an invalid element",
+			args:     []string{"--each", "", "tondjson", `[1, 2, nope]`},
+			exitCode: 1,
+			stdout:   "1\n2\n",
+		},
+		{
+			name:     "unsupported command",
+			args:     []string{"--each", "", "encode", `[1]`},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
+
+func TestRunEachStdin(t *testing.T) {
+	// stdin breaks off after two elements; streaming it writes both before
+	// failing, where reading it whole first would write nothing
+	failure := errors.New("connection reset")
+	stdin := io.MultiReader(strings.NewReader(`[{"id": 1}, {"id": 2}, {"id"`), iotest.ErrReader(failure))
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--each", "", "tondjson"}, stdin, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1 (stderr: %s)", code, stderr.String())
+	}
+	expected := "{\"id\":1}\n{\"id\":2}\n"
+	if stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+	if !strings.Contains(stderr.String(), "connection reset") {
+		t.Errorf("run() stderr = %q, want the read error", stderr.String())
+	}
+}
+
+func TestRunEachSampleRate(t *testing.T) {
+	elements := make([]string, 100)
+	for i := range elements {
+		elements[i] = strconv.Itoa(i)
+	}
+	input := "[" + strings.Join(elements, ",") + "]"
+
+	sample := func(args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		args = append(args, "--each", "", "tondjson", input)
+		if code := run(args, nil, &stdout, &stderr); code != 0 {
+			t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	first := sample("--seed", "7", "--sample-rate", "0.1")
+	if again := sample("--seed", "7", "--sample-rate", "0.1"); again != first {
+		t.Errorf("run() with the same seed = %q, want %q", again, first)
+	}
+	if other := sample("--seed", "8", "--sample-rate", "0.1"); other == first {
+		t.Errorf("run() with another seed gave the same sample %q", other)
+	}
+	if n := strings.Count(first, "\n"); n < 2 || n > 25 {
+		t.Errorf("run() passed %d of 100 elements at rate 0.1", n)
+	}
+	// Filtering does not change which elements are drawn
+	filtered := sample("--seed", "7", "--sample-rate", "0.1", "--expr", "value >= 50")
+	var expected strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(first), "\n") {
+		if n, _ := strconv.Atoi(line); n >= 50 {
+			expected.WriteString(line + "\n")
+		}
+	}
+	if filtered != expected.String() {
+		t.Errorf("run() with --expr = %q, want %q", filtered, expected.String())
+	}
+	if all := sample("--sample-rate", "1"); strings.Count(all, "\n") != 100 {
+		t.Errorf("run() at rate 1 passed %d elements, want 100", strings.Count(all, "\n"))
+	}
+}
+
+func TestRunEachSampleRateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "without --each", args: []string{"--sample-rate", "0.5", "tondjson", `[1]`}, wantErr: "--sample-rate requires --each"},
+		{name: "zero", args: []string{"--sample-rate", "0", "--each", "", "tondjson", `[1]`}, wantErr: "greater than 0 and at most 1"},
+		{name: "above one", args: []string{"--sample-rate", "1.5", "--each", "", "tondjson", `[1]`}, wantErr: "greater than 0 and at most 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != 1 {
+				t.Errorf("run() = %d, want 1", code)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("run() stderr = %q, want %q", stderr.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+// chunkReader returns its chunks one read at a time, recording what had
+// been written to out before each read
+type chunkReader struct {
+	chunks []string
+	out    *bytes.Buffer
+	seen   []string
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	r.seen = append(r.seen, r.out.String())
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestRunEachLineBuffered(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		// seen is what stdout held before each read of stdin
+		seen []string
+	}{
+		{name: "buffered", args: []string{"--each", "", "tondjson"}, seen: []string{"", "", ""}},
+		{name: "line buffered", args: []string{"--each", "", "--line-buffered", "tondjson"}, seen: []string{"", "1\n", "1\n2\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			stdin := &chunkReader{chunks: []string{"[1,", "2,", "3]"}, out: &stdout}
+			if code := run(tt.args, stdin, &stdout, &stderr); code != 0 {
+				t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+			}
+			if stdout.String() != "1\n2\n3\n" {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), "1\n2\n3\n")
+			}
+			if !reflect.DeepEqual(stdin.seen, tt.seen) {
+				t.Errorf("stdout before each read = %q, want %q", stdin.seen, tt.seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// encodingReport describes the text encoding detected in raw input bytes
+type encodingReport struct {
+	Encoding         string
+	BOM              bool
+	Size             int
+	InvalidSequences int
+	FirstInvalid     int // byte offset of the first invalid sequence, or -1
+}
+
+// detectEncoding inspects raw input bytes and reports their text encoding
+// without transforming them. A byte order mark is trusted when present;
+// otherwise the zero-byte pattern of the first two bytes is used to spot
+// UTF-16, as JSON text always starts with an ASCII character.
+func detectEncoding(data []byte) encodingReport {
+	report := encodingReport{Encoding: "UTF-8", Size: len(data), FirstInvalid: -1}
+
+	body := data
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		report.BOM = true
+		body = data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE):
+		report.Encoding = "UTF-16LE"
+		report.BOM = true
+		body = data[len(bomUTF16LE):]
+	case bytes.HasPrefix(data, bomUTF16BE):
+		report.Encoding = "UTF-16BE"
+		report.BOM = true
+		body = data[len(bomUTF16BE):]
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		report.Encoding = "UTF-16BE"
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		report.Encoding = "UTF-16LE"
+	}
+
+	offset := len(data) - len(body)
+	if report.Encoding == "UTF-8" {
+		countInvalidUTF8(body, offset, &report)
+	} else {
+		countInvalidUTF16(body, offset, report.Encoding == "UTF-16BE", &report)
+	}
+
+	return report
+}
+
+// countInvalidUTF8 records every byte sequence that is not valid UTF-8
+func countInvalidUTF8(data []byte, offset int, report *encodingReport) {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size <= 1 {
+			report.addInvalid(offset + i)
+		}
+		i += size
+	}
+}
+
+// countInvalidUTF16 records unpaired surrogates and a dangling odd byte
+func countInvalidUTF16(data []byte, offset int, bigEndian bool, report *encodingReport) {
+	unit := func(i int) uint16 {
+		if bigEndian {
+			return uint16(data[i])<<8 | uint16(data[i+1])
+		}
+		return uint16(data[i+1])<<8 | uint16(data[i])
+	}
+
+	i := 0
+	for ; i+1 < len(data); i += 2 {
+		u := rune(unit(i))
+		if !utf16.IsSurrogate(u) {
+			continue
+		}
+		if u < 0xDC00 && i+3 < len(data) {
+			if next := rune(unit(i + 2)); next >= 0xDC00 && next <= 0xDFFF {
+				i += 2
+				continue
+			}
+		}
+		report.addInvalid(offset + i)
+	}
+	if i < len(data) {
+		report.addInvalid(offset + i)
+	}
+}
+
+func (r *encodingReport) addInvalid(offset int) {
+	if r.FirstInvalid < 0 {
+		r.FirstInvalid = offset
+	}
+	r.InvalidSequences++
+}
+
+// String formats the report as one "field: value" line per property
+func (r encodingReport) String() string {
+	lines := []string{
+		fmt.Sprintf("encoding: %s", r.Encoding),
+		fmt.Sprintf("bom: %t", r.BOM),
+		fmt.Sprintf("bytes: %d", r.Size),
+		fmt.Sprintf("invalid sequences: %d", r.InvalidSequences),
+	}
+	if r.FirstInvalid >= 0 {
+		lines = append(lines, fmt.Sprintf("first invalid offset: %d", r.FirstInvalid))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// toUTF16 encodes s as UTF-16 in the requested byte order
+func toUTF16(s string, bigEndian bool) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	return out
+}
+
+func TestDetectEncoding(t *testing.T) {
+	doc := `{"key": "välue"}`
+
+	tests := []struct {
+		name         string
+		input        []byte
+		encoding     string
+		bom          bool
+		invalid      int
+		firstInvalid int
+	}{
+		{
+			name:         "plain UTF-8",
+			input:        []byte(doc),
+			encoding:     "UTF-8",
+			firstInvalid: -1,
+		},
+		{
+			name:         "UTF-8 with BOM",
+			input:        append([]byte{0xEF, 0xBB, 0xBF}, doc...),
+			encoding:     "UTF-8",
+			bom:          true,
+			firstInvalid: -1,
+		},
+		{
+			name:         "invalid UTF-8 bytes",
+			input:        []byte("{\"key\": \"\xff\xfe\"}"),
+			encoding:     "UTF-8",
+			invalid:      2,
+			firstInvalid: 9,
+		},
+		{
+			name:         "UTF-16LE with BOM",
+			input:        append([]byte{0xFF, 0xFE}, toUTF16(doc, false)...),
+			encoding:     "UTF-16LE",
+			bom:          true,
+			firstInvalid: -1,
+		},
+		{
+			name:         "UTF-16BE with BOM",
+			input:        append([]byte{0xFE, 0xFF}, toUTF16(doc, true)...),
+			encoding:     "UTF-16BE",
+			bom:          true,
+			firstInvalid: -1,
+		},
+		{
+			name:         "UTF-16LE without BOM",
+			input:        toUTF16(doc, false),
+			encoding:     "UTF-16LE",
+			firstInvalid: -1,
+		},
+		{
+			name:         "UTF-16BE surrogate pair",
+			input:        toUTF16(`["😀"]`, true),
+			encoding:     "UTF-16BE",
+			firstInvalid: -1,
+		},
+		{
+			name:         "UTF-16LE unpaired surrogate",
+			input:        append(toUTF16(`["`, false), 0x00, 0xD8, '"', 0x00, ']', 0x00),
+			encoding:     "UTF-16LE",
+			invalid:      1,
+			firstInvalid: 4,
+		},
+		{
+			name:         "UTF-16BE odd trailing byte",
+			input:        append(toUTF16(`[]`, true), 0x00),
+			encoding:     "UTF-16BE",
+			invalid:      1,
+			firstInvalid: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := detectEncoding(tt.input)
+			if report.Encoding != tt.encoding {
+				t.Errorf("detectEncoding() encoding = %v, want %v", report.Encoding, tt.encoding)
+			}
+			if report.BOM != tt.bom {
+				t.Errorf("detectEncoding() bom = %v, want %v", report.BOM, tt.bom)
+			}
+			if report.InvalidSequences != tt.invalid {
+				t.Errorf("detectEncoding() invalid sequences = %v, want %v", report.InvalidSequences, tt.invalid)
+			}
+			if report.FirstInvalid != tt.firstInvalid {
+				t.Errorf("detectEncoding() first invalid = %v, want %v", report.FirstInvalid, tt.firstInvalid)
+			}
+			if report.Size != len(tt.input) {
+				t.Errorf("detectEncoding() size = %v, want %v", report.Size, len(tt.input))
+			}
+		})
+	}
+}
+
+func TestEncodingReportString(t *testing.T) {
+	report := detectEncoding(append([]byte{0xFF, 0xFE}, toUTF16(`{}`, false)...))
+	expected := "encoding: UTF-16LE\nbom: true\nbytes: 6\ninvalid sequences: 0"
+	if got := report.String(); got != expected {
+		t.Errorf("String() = %q, want %q", got, expected)
+	}
+
+	report = detectEncoding([]byte("{\xff}"))
+	if got := report.String(); !strings.HasSuffix(got, "\nfirst invalid offset: 1") {
+		t.Errorf("String() = %q, want first invalid offset line", got)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// envReference matches a ${VAR} reference. The bare $VAR form is not
+// expanded, so that keys like "$ref" are left alone.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces the ${VAR} references in s with the values of the
+// environment variables. Unset variables are an error.
+func expandEnv(s string) (string, error) {
+	var err error
+	expanded := envReference.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envReference.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value
+	})
+	return expanded, err
+}
+
+// expandEnvKeys expands ${VAR} references in every object key. An expanded
+// key that matches another key of the same object is an error unless
+// overwrite is set, in which case the expanded key's value replaces it.
+func expandEnvKeys(value interface{}, overwrite bool) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		var templated []string
+		for _, key := range sortedKeys(v) {
+			if envReference.MatchString(key) {
+				templated = append(templated, key)
+				continue
+			}
+			child, err := expandEnvKeys(v[key], overwrite)
+			if err != nil {
+				return nil, err
+			}
+			expanded[key] = child
+		}
+		for _, key := range templated {
+			newKey, err := expandEnv(key)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %v", key, err)
+			}
+			if _, exists := expanded[newKey]; exists && !overwrite {
+				return nil, fmt.Errorf("key %q expands to %q, which already exists (use --overwrite to replace it)", key, newKey)
+			}
+			child, err := expandEnvKeys(v[key], overwrite)
+			if err != nil {
+				return nil, err
+			}
+			expanded[newKey] = child
+		}
+		return expanded, nil
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, child := range v {
+			child, err := expandEnvKeys(child, overwrite)
+			if err != nil {
+				return nil, err
+			}
+			expanded[i] = child
+		}
+		return expanded, nil
+	default:
+		return value, nil
+	}
+}
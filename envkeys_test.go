@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestExpandEnvKeys(t *testing.T) {
+	t.Setenv("JE_TEST_ENV", "prod")
+	t.Setenv("JE_TEST_REGION", "eu")
+
+	tests := []struct {
+		name      string
+		input     string
+		overwrite bool
+		expected  string
+		wantErr   bool
+	}{
+		{
+			name:     "templated key",
+			input:    `{"db_${JE_TEST_ENV}": {"host": "h"}}`,
+			expected: `{"db_prod":{"host":"h"}}`,
+		},
+		{
+			name:     "several references and nesting",
+			input:    `[{"${JE_TEST_REGION}-${JE_TEST_ENV}": {"${JE_TEST_ENV}": 1}}]`,
+			expected: `[{"eu-prod":{"prod":1}}]`,
+		},
+		{
+			name:     "bare dollar and values untouched",
+			input:    `{"$ref": "#/a", "$JE_TEST_ENV": "${JE_TEST_ENV}"}`,
+			expected: `{"$JE_TEST_ENV":"${JE_TEST_ENV}","$ref":"#/a"}`,
+		},
+		{
+			name:    "collision",
+			input:   `{"${JE_TEST_ENV}": 1, "prod": 2}`,
+			wantErr: true,
+		},
+		{
+			name:      "collision with overwrite",
+			input:     `{"${JE_TEST_ENV}": 1, "prod": 2}`,
+			overwrite: true,
+			expected:  `{"prod":1}`,
+		},
+		{
+			name:    "unset variable",
+			input:   `{"${JE_TEST_UNSET}": 1}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input interface{}
+			if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			result, err := expandEnvKeys(input, tt.overwrite)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandEnvKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(encoded) != tt.expected {
+				t.Errorf("expandEnvKeys() = %s, want %s", encoded, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunEnvsubstKeys(t *testing.T) {
+	t.Setenv("JE_TEST_SERVICE", "billing")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--envsubst-keys", "encode", `{"${JE_TEST_SERVICE}_url": "http://x"}`}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	expected := `"{\"billing_url\":\"http://x\"}"` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// escapeReport counts the escape sequences in a quoted string, grouped the
+// way they matter when embedding: quotes, backslashes, control characters
+// and non-ASCII characters
+type escapeReport struct {
+	Quotes      int
+	Backslashes int
+	Control     map[string]int // keyed by escape sequence, e.g. \n
+	NonASCII    map[string]int // keyed by escape sequence, e.g. \u00a0
+}
+
+// countEscapes scans a string produced by strconv.Quote and counts each
+// escape sequence it contains
+func countEscapes(quoted string) escapeReport {
+	report := escapeReport{Control: make(map[string]int), NonASCII: make(map[string]int)}
+
+	body := strings.TrimSuffix(strings.TrimPrefix(quoted, `"`), `"`)
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' || i+1 >= len(body) {
+			continue
+		}
+		length := 2
+		switch body[i+1] {
+		case '"':
+			report.Quotes++
+		case '\\':
+			report.Backslashes++
+		case 'x':
+			length = 4
+		case 'u':
+			length = 6
+		case 'U':
+			length = 10
+		}
+		if length > len(body)-i {
+			length = len(body) - i
+		}
+
+		sequence := body[i : i+length]
+		switch {
+		case body[i+1] == '"' || body[i+1] == '\\':
+		case isControlEscape(sequence):
+			report.Control[sequence]++
+		default:
+			report.NonASCII[sequence]++
+		}
+		i += length - 1
+	}
+	return report
+}
+
+// isControlEscape reports whether an escape sequence stands for an ASCII
+// control character (including DEL)
+func isControlEscape(sequence string) bool {
+	switch sequence[1] {
+	case 'a', 'b', 'f', 'n', 'r', 't', 'v':
+		return true
+	case 'x':
+		return sequence <= `\x1f` || sequence == `\x7f`
+	case 'u':
+		return sequence <= `\u001f` || sequence == `\u007f`
+	}
+	return false
+}
+
+// writeEscapeReport prints the escape counts, one category per line
+func writeEscapeReport(w io.Writer, report escapeReport) {
+	fmt.Fprintf(w, "quotes: %d\n", report.Quotes)
+	fmt.Fprintf(w, "backslashes: %d\n", report.Backslashes)
+	fmt.Fprintf(w, "control characters: %s\n", formatEscapeCounts(report.Control))
+	fmt.Fprintf(w, "non-ASCII: %s\n", formatEscapeCounts(report.NonASCII))
+}
+
+// formatEscapeCounts renders a total followed by each sequence's count,
+// e.g. "3 (\n 2, \t 1)"
+func formatEscapeCounts(counts map[string]int) string {
+	total := 0
+	sequences := make([]string, 0, len(counts))
+	for sequence, count := range counts {
+		total += count
+		sequences = append(sequences, sequence)
+	}
+	if total == 0 {
+		return "0"
+	}
+
+	sort.Strings(sequences)
+	details := make([]string, len(sequences))
+	for i, sequence := range sequences {
+		details[i] = fmt.Sprintf("%s %d", sequence, counts[sequence])
+	}
+	return fmt.Sprintf("%d (%s)", total, strings.Join(details, ", "))
+}
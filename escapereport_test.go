@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCountEscapes(t *testing.T) {
+	// Quotes, a backslash, tab, newline, two other control characters, a
+	// printable non-ASCII letter and a non-printable non-ASCII space
+	text := "say \"hi\"\t\\\n\x01\x7f é\u00a0"
+
+	encoded, err := encodeValue(map[string]interface{}{"text": text})
+	if err != nil {
+		t.Fatalf("encodeValue() error = %v", err)
+	}
+	report := countEscapes(encoded)
+
+	// json.Marshal escapes the tab, newline and \x01 itself, so quoting its
+	// output only adds quotes and backslashes for them; DEL and the
+	// non-breaking space pass through json.Marshal and are escaped by quoting
+	expected := escapeReport{
+		Quotes:      6,
+		Backslashes: 7,
+		Control:     map[string]int{`\x7f`: 1},
+		NonASCII:    map[string]int{`\u00a0`: 1},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("countEscapes() = %+v, want %+v", report, expected)
+	}
+}
+
+func TestCountEscapesRawControlCharacters(t *testing.T) {
+	report := countEscapes(`"a\nb\tc\x01d\x7f\u2028\xff\U0001f600"`)
+	expected := escapeReport{
+		Control:  map[string]int{`\n`: 1, `\t`: 1, `\x01`: 1, `\x7f`: 1},
+		NonASCII: map[string]int{`\u2028`: 1, `\xff`: 1, `\U0001f600`: 1},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("countEscapes() = %+v, want %+v", report, expected)
+	}
+}
+
+func TestWriteEscapeReport(t *testing.T) {
+	var buf bytes.Buffer
+	writeEscapeReport(&buf, escapeReport{
+		Quotes:      2,
+		Backslashes: 1,
+		Control:     map[string]int{`\t`: 1, `\n`: 2},
+		NonASCII:    map[string]int{},
+	})
+
+	expected := "quotes: 2\nbackslashes: 1\ncontrol characters: 3 (\\n 2, \\t 1)\nnon-ASCII: 0\n"
+	if buf.String() != expected {
+		t.Errorf("writeEscapeReport() = %q, want %q", buf.String(), expected)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// decodeJSONPreservingEscapes decodes an encoded JSON string like decodeJSON,
+// but keeps optional escapes such as \/ and \u0041 in their original form
+// wherever they fall inside a string of the inner document. Escapes that
+// carry structure (quotes, backslashes, control characters) or that fall
+// outside inner strings are decoded as usual.
+func decodeJSONPreservingEscapes(encodedStr string) (string, error) {
+	// Let the standard decoder reject malformed input before walking the tokens
+	var check string
+	if err := json.Unmarshal([]byte(encodedStr), &check); err != nil {
+		return "", fmt.Errorf("failed to decode JSON: %v", err)
+	}
+
+	literal := strings.TrimSpace(encodedStr)
+	literal = literal[1 : len(literal)-1]
+
+	var out strings.Builder
+	inString, escaped := false, false
+	for i := 0; i < len(literal); {
+		r, original, size := nextStringChar(literal[i:])
+		i += size
+
+		if inString && !escaped && original != "" && preservable(r) {
+			out.WriteString(original)
+			continue
+		}
+		out.WriteRune(r)
+
+		// Track whether the next character lands inside an inner string
+		switch {
+		case !inString:
+			inString = r == '"'
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			inString = false
+		}
+	}
+
+	decoded := out.String()
+	var jsonData interface{}
+	if err := json.Unmarshal([]byte(decoded), &jsonData); err != nil {
+		return "", fmt.Errorf("decoded result is not valid JSON: %v", err)
+	}
+	return decoded, nil
+}
+
+// nextStringChar reads one character from the body of a valid JSON string
+// literal. It returns the decoded rune, the original escape sequence if the
+// character was escaped, and the number of bytes consumed.
+func nextStringChar(s string) (rune, string, int) {
+	if s[0] != '\\' {
+		r, size := utf8.DecodeRuneInString(s)
+		return r, "", size
+	}
+
+	switch s[1] {
+	case 'u':
+		r := hexRune(s[2:6])
+		if utf16.IsSurrogate(r) && len(s) >= 12 && s[6] == '\\' && s[7] == 'u' {
+			if pair := utf16.DecodeRune(r, hexRune(s[8:12])); pair != unicode.ReplacementChar {
+				return pair, s[:12], 12
+			}
+		}
+		if utf16.IsSurrogate(r) {
+			r = unicode.ReplacementChar
+		}
+		return r, s[:6], 6
+	case 'b':
+		return '\b', s[:2], 2
+	case 'f':
+		return '\f', s[:2], 2
+	case 'n':
+		return '\n', s[:2], 2
+	case 'r':
+		return '\r', s[:2], 2
+	case 't':
+		return '\t', s[:2], 2
+	default:
+		// \" \\ and \/ stand for the character itself
+		return rune(s[1]), s[:2], 2
+	}
+}
+
+// preservable reports whether a character may appear unescaped inside a JSON
+// string, so that keeping its escaped form does not change the document
+func preservable(r rune) bool {
+	return r != '"' && r != '\\' && r >= 0x20
+}
+
+// unescapeASCII rewrites \uXXXX escapes of printable ASCII characters inside
+// the strings of a valid JSON document as the characters themselves, so
+// that \u0041 becomes A. Escapes of quotes, backslashes, control and
+// non-ASCII characters, and all other escape forms, are kept.
+func unescapeASCII(doc string) string {
+	var out strings.Builder
+	inString := false
+	for i := 0; i < len(doc); {
+		if !inString {
+			inString = doc[i] == '"'
+			out.WriteByte(doc[i])
+			i++
+			continue
+		}
+
+		r, original, size := nextStringChar(doc[i:])
+		switch {
+		case original == "":
+			inString = r != '"'
+			out.WriteString(doc[i : i+size])
+		case strings.HasPrefix(original, "\\u") && r >= 0x20 && r < 0x7f && r != '"' && r != '\\':
+			out.WriteRune(r)
+		default:
+			out.WriteString(original)
+		}
+		i += size
+	}
+	return out.String()
+}
+
+// checkDecodedUTF8 reports the first place where decoding a valid JSON
+// string literal would not yield valid UTF-8: an unpaired surrogate escape
+// or a byte that is not UTF-8. The standard decoder silently turns both
+// into U+FFFD. Offsets count bytes from the start of encodedStr.
+func checkDecodedUTF8(encodedStr string) error {
+	body := strings.TrimLeft(encodedStr, " \t\r\n")
+	offset := len(encodedStr) - len(body) + 1
+	body = strings.TrimRight(body, " \t\r\n")
+	body = body[1 : len(body)-1]
+
+	for i := 0; i < len(body); {
+		if body[i] == '\\' {
+			if body[i+1] != 'u' {
+				i += 2
+				continue
+			}
+			r := hexRune(body[i+2 : i+6])
+			if !utf16.IsSurrogate(r) {
+				i += 6
+				continue
+			}
+			if i+12 <= len(body) && body[i+6] == '\\' && body[i+7] == 'u' &&
+				utf16.DecodeRune(r, hexRune(body[i+8:i+12])) != unicode.ReplacementChar {
+				i += 12
+				continue
+			}
+			return fmt.Errorf("unpaired surrogate %s at offset %d does not decode to valid UTF-8", body[i:i+6], offset+i)
+		}
+		r, size := utf8.DecodeRuneInString(body[i:])
+		if r == utf8.RuneError && size == 1 {
+			return fmt.Errorf("invalid UTF-8 byte 0x%02x at offset %d", body[i], offset+i)
+		}
+		i += size
+	}
+	return nil
+}
+
+func hexRune(hex string) rune {
+	n, _ := strconv.ParseUint(hex, 16, 16)
+	return rune(n)
+}
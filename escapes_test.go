@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSONPreservingEscapes(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		normal    string
+		preserved string
+		wantErr   bool
+	}{
+		{
+			name:      "escaped slash",
+			input:     `"{\"url\": \"a\/b\"}"`,
+			normal:    `{"url": "a/b"}`,
+			preserved: `{"url": "a\/b"}`,
+		},
+		{
+			name:      "unicode escape of ASCII",
+			input:     `"{\"k\": \"\u0041\"}"`,
+			normal:    `{"k": "A"}`,
+			preserved: `{"k": "\u0041"}`,
+		},
+		{
+			name:      "surrogate pair",
+			input:     `"[\"\ud83d\ude00\"]"`,
+			normal:    `["😀"]`,
+			preserved: `["\ud83d\ude00"]`,
+		},
+		{
+			name:      "escapes outside inner strings are decoded",
+			input:     `"{\u0022k\u0022:\u00201}"`,
+			normal:    `{"k": 1}`,
+			preserved: `{"k": 1}`,
+		},
+		{
+			name:      "escapes within inner escapes are decoded",
+			input:     `"{\"k\": \"\\u0041 \\\/\"}"`,
+			normal:    `{"k": "\u0041 \/"}`,
+			preserved: `{"k": "\u0041 \/"}`,
+		},
+		{
+			name:      "structural quote escape is decoded",
+			input:     `"{\"k\": \"x\u0022}"`,
+			normal:    `{"k": "x"}`,
+			preserved: `{"k": "x"}`,
+		},
+		{
+			name:    "invalid encoded string",
+			input:   `not-a-json-string`,
+			wantErr: true,
+		},
+		{
+			name:    "decoded result not valid JSON",
+			input:   `"not json \/"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := decodeJSONPreservingEscapes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeJSONPreservingEscapes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if result != tt.preserved {
+				t.Errorf("decodeJSONPreservingEscapes() = %v, want %v", result, tt.preserved)
+			}
+
+			// Without the flag the same input is normalized
+			normal, err := decodeJSON(tt.input)
+			if err != nil {
+				t.Fatalf("decodeJSON() error = %v", err)
+			}
+			if normal != tt.normal {
+				t.Errorf("decodeJSON() = %v, want %v", normal, tt.normal)
+			}
+		})
+	}
+}
+
+func TestUnescapeASCII(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "over-escaped ASCII",
+			input:    `{"\u006e\u0061me": "\u0048\u0069, \u0057orld\u0021"}`,
+			expected: `{"name": "Hi, World!"}`,
+		},
+		{
+			name:     "HTML-safe escapes",
+			input:    `["\u003ca href=\u0026\u003e"]`,
+			expected: `["<a href=&>"]`,
+		},
+		{
+			name:     "required escapes kept",
+			input:    `["\u0022 \u005c \u000a \n \" \\ \u001f"]`,
+			expected: `["\u0022 \u005c \u000a \n \" \\ \u001f"]`,
+		},
+		{
+			name:     "non-ASCII and DEL escapes kept",
+			input:    `["\u00e9 \ud83d\ude00 \u007f \/"]`,
+			expected: `["\u00e9 \ud83d\ude00 \u007f \/"]`,
+		},
+		{
+			name:     "escaped backslash before u",
+			input:    `["\\u0041"]`,
+			expected: `["\\u0041"]`,
+		},
+		{
+			name:     "text outside strings untouched",
+			input:    `{"a": [1, true, null], "b": "\u0041"}`,
+			expected: `{"a": [1, true, null], "b": "A"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unescapeASCII(tt.input); got != tt.expected {
+				t.Errorf("unescapeASCII(%s) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckDecodedUTF8(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "plain text", input: `"{\"k\": \"v\"}"`},
+		{name: "escaped non-ASCII", input: `"{\"k\": \"\u00e9 é\"}"`},
+		{name: "surrogate pair", input: `"{\"k\": \"\ud83d\ude00\"}"`},
+		{name: "literal replacement character", input: `"{\"k\": \"\ufffd\"}"`},
+		{name: "escaped backslash before u", input: `"{\"k\": \"\\\\ud800\"}"`},
+		{name: "surrounding whitespace", input: " \n\"\\u00e9\" "},
+		{
+			name:    "lone high surrogate",
+			input:   `"{\"k\": \"\ud800\"}"`,
+			wantErr: `unpaired surrogate \ud800 at offset 11 does not decode to valid UTF-8`,
+		},
+		{
+			name:    "lone low surrogate after whitespace",
+			input:   "  \"x\\ude00\"",
+			wantErr: `unpaired surrogate \ude00 at offset 4 does not decode to valid UTF-8`,
+		},
+		{
+			name:    "high surrogate followed by a non-surrogate",
+			input:   `"\ud83d\u0041"`,
+			wantErr: `unpaired surrogate \ud83d at offset 1 does not decode to valid UTF-8`,
+		},
+		{
+			name:    "invalid byte",
+			input:   "\"ab\xffc\"",
+			wantErr: `invalid UTF-8 byte 0xff at offset 3`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDecodedUTF8(tt.input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkDecodedUTF8() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("checkDecodedUTF8() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunStrictUTF8Decode(t *testing.T) {
+	input := `"{\"k\": \"\ud800\"}"`
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"decode", input}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if expected := "{\"k\": \"\ufffd\"}\n"; stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--strict-utf8-decode", "decode", input}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "offset 11") {
+		t.Errorf("run() stderr = %q, want the offset of the surrogate", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--strict-utf8-decode", "decode", `"{\"k\": \"\u00e9\"}"`}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if expected := "{\"k\": \"é\"}\n"; stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// decodeExplanation describes what decoding an encoded JSON string does
+type decodeExplanation struct {
+	EncodedBytes int
+	DecodedBytes int
+	Escapes      int
+	InnerErr     error // nil when the decoded text is valid JSON
+}
+
+// explainDecode measures an encoded JSON string literal: its length, the
+// length of the text it holds, and how many escape sequences stand for a
+// character of that text. With base64 set the input is base64 decoded
+// first, as decode does.
+func explainDecode(input string, base64Input bool) (decodeExplanation, error) {
+	if base64Input {
+		decoded, err := base64.StdEncoding.DecodeString(input)
+		if err != nil {
+			return decodeExplanation{}, fmt.Errorf("decoding base64: %v", err)
+		}
+		input = string(decoded)
+	}
+	var text string
+	if err := json.Unmarshal([]byte(input), &text); err != nil {
+		return decodeExplanation{}, fmt.Errorf("input is not a JSON string: %v", err)
+	}
+
+	explanation := decodeExplanation{EncodedBytes: len(input), DecodedBytes: len(text)}
+	literal := strings.TrimSpace(input)
+	literal = literal[1 : len(literal)-1]
+	for i := 0; i < len(literal); {
+		_, original, size := nextStringChar(literal[i:])
+		if original != "" {
+			explanation.Escapes++
+		}
+		i += size
+	}
+	var inner interface{}
+	explanation.InnerErr = json.Unmarshal([]byte(text), &inner)
+	return explanation, nil
+}
+
+// writeDecodeExplanation prints an explanation, one measure per line
+func writeDecodeExplanation(w io.Writer, e decodeExplanation) {
+	fmt.Fprintf(w, "encoded length: %d bytes\n", e.EncodedBytes)
+	fmt.Fprintf(w, "decoded length: %d bytes\n", e.DecodedBytes)
+	fmt.Fprintf(w, "escape sequences: %d\n", e.Escapes)
+	if e.InnerErr != nil {
+		fmt.Fprintf(w, "inner JSON: invalid (%v)\n", e.InnerErr)
+	} else {
+		fmt.Fprintf(w, "inner JSON: valid\n")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestExplainDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		base64    bool
+		expected  decodeExplanation
+		wantInner bool
+		wantErr   bool
+	}{
+		{
+			name:      "known encoded input",
+			input:     `"{\"key\": \"a\\nb\"}"`,
+			expected:  decodeExplanation{EncodedBytes: 22, DecodedBytes: 15, Escapes: 5},
+			wantInner: true,
+		},
+		{
+			name:     "invalid inner JSON",
+			input:    `"{\"key\": "`,
+			expected: decodeExplanation{EncodedBytes: 12, DecodedBytes: 8, Escapes: 2},
+		},
+		{
+			name:      "base64 input",
+			input:     base64.StdEncoding.EncodeToString([]byte(`"[\"x\"]"`)),
+			base64:    true,
+			expected:  decodeExplanation{EncodedBytes: 9, DecodedBytes: 5, Escapes: 2},
+			wantInner: true,
+		},
+		{name: "not a string literal", input: `{"key": 1}`, wantErr: true},
+		{name: "invalid base64", input: `%%`, base64: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := explainDecode(tt.input, tt.base64)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("explainDecode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got.InnerErr == nil) != tt.wantInner {
+				t.Errorf("explainDecode() inner error = %v, want valid %v", got.InnerErr, tt.wantInner)
+			}
+			got.InnerErr = nil
+			if got != tt.expected {
+				t.Errorf("explainDecode() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunExplainDecode(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--explain", "decode", `"{\"key\": \"value\"}"`}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if stdout.String() != "{\"key\": \"value\"}\n" {
+		t.Errorf("run() stdout = %q, want the decoded data", stdout.String())
+	}
+	expected := "encoded length: 22 bytes\ndecoded length: 16 bytes\nescape sequences: 4\ninner JSON: valid\n"
+	if stderr.String() != expected {
+		t.Errorf("run() stderr = %q, want %q", stderr.String(), expected)
+	}
+}
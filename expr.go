@@ -0,0 +1,510 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprFunc evaluates a compiled --expr expression against a value
+type exprFunc func(value interface{}) (interface{}, error)
+
+// compileExpr parses a --expr expression. The language is deliberately
+// small and has no side effects:
+//
+//	value, value.name, value["odd key"], value.items[0]   the input and its members
+//	"text", 'text', 12, 1.5e3, true, false, null          literals
+//	== != < <= > >=                                       comparison
+//	&& || !                                               logic on booleans
+//	+ - * /                                               arithmetic; + also joins strings
+//
+// A member or element that does not exist is null. == and != compare any
+// JSON values; ordering needs two numbers or two strings, logic needs
+// booleans and arithmetic needs numbers, otherwise evaluation fails. So that
+// a filter on an optional member drops the elements that lack it, ordering
+// against null is false and logic reads null as false.
+func compileExpr(source string) (exprFunc, error) {
+	tokens, err := lexExpr(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --expr: %v", err)
+	}
+	p := &exprParser{tokens: tokens}
+	fn, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEnd {
+		err = fmt.Errorf("unexpected %s at offset %d", p.peek(), p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid --expr: %v", err)
+	}
+	return fn, nil
+}
+
+// filterExpr evaluates an expression for each array element. Elements for
+// which it yields a boolean are kept when it is true and dropped when it is
+// false; any other result replaces the element.
+func filterExpr(array []interface{}, source string) ([]interface{}, error) {
+	fn, err := compileExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, 0, len(array))
+	for i, element := range array {
+		mapped, keep, err := evalElement(element, fn)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %v", i, err)
+		}
+		if keep {
+			result = append(result, mapped)
+		}
+	}
+	return result, nil
+}
+
+// evalElement evaluates an expression for one element, returning the
+// element's replacement and whether it is kept
+func evalElement(element interface{}, fn exprFunc) (interface{}, bool, error) {
+	value, err := fn(element)
+	if err != nil {
+		return nil, false, fmt.Errorf("--expr: %v", err)
+	}
+	if keep, isBool := value.(bool); isBool {
+		return element, keep, nil
+	}
+	return value, true, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func (t exprToken) String() string {
+	if t.kind == tokenEnd {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// exprOperators lists the operators, longest first so that "<=" is not
+// read as "<" followed by "="
+var exprOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", "."}
+
+func lexExpr(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(source) && (strings.IndexByte("0123456789.eE", source[i]) >= 0 ||
+				((source[i] == '+' || source[i] == '-') && (source[i-1] == 'e' || source[i-1] == 'E'))) {
+				i++
+			}
+			n, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", source[start:i], start)
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: source[start:i], num: n, pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(source) {
+					return nil, fmt.Errorf("unterminated string at offset %d", start)
+				}
+				if source[i] == c {
+					i++
+					break
+				}
+				if source[i] == '\\' && i+1 < len(source) {
+					i++
+					switch source[i] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(source[i])
+					}
+					continue
+				}
+				b.WriteByte(source[i])
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, text: b.String(), pos: start})
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(source) && (source[i] == '_' || unicode.IsLetter(rune(source[i])) || unicode.IsDigit(rune(source[i]))) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: source[start:i], pos: start})
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, exprToken{kind: tokenOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(tokens, exprToken{kind: tokenEnd, pos: len(source)}), nil
+}
+
+type exprParser struct {
+	tokens []exprToken
+	next   int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.next]
+}
+
+// accept consumes the next token if it is one of the given operators
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.next++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return fmt.Errorf("expected %q at offset %d, found %s", op, p.peek().pos, p.peek())
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprFunc, error) {
+	left, err := p.parseAnd()
+	for err == nil {
+		if _, ok := p.accept("||"); !ok {
+			break
+		}
+		var right exprFunc
+		if right, err = p.parseAnd(); err == nil {
+			left = logical(left, right, true)
+		}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseAnd() (exprFunc, error) {
+	left, err := p.parseComparison()
+	for err == nil {
+		if _, ok := p.accept("&&"); !ok {
+			break
+		}
+		var right exprFunc
+		if right, err = p.parseComparison(); err == nil {
+			left = logical(left, right, false)
+		}
+	}
+	return left, err
+}
+
+// logical builds a short-circuiting || (or is true) or && operator
+func logical(left, right exprFunc, or bool) exprFunc {
+	return func(value interface{}) (interface{}, error) {
+		l, err := evalBool(left, value)
+		if err != nil || l == or {
+			return l, err
+		}
+		return evalBool(right, value)
+	}
+}
+
+func evalBool(fn exprFunc, value interface{}) (bool, error) {
+	result, err := fn(value)
+	if err != nil {
+		return false, err
+	}
+	if result == nil {
+		return false, nil
+	}
+	b, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a boolean, got %s", jsonType(result))
+	}
+	return b, nil
+}
+
+func (p *exprParser) parseComparison() (exprFunc, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	return func(value interface{}) (interface{}, error) {
+		l, err := left(value)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right(value)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "==":
+			return reflect.DeepEqual(l, r), nil
+		case "!=":
+			return !reflect.DeepEqual(l, r), nil
+		}
+		if l == nil || r == nil {
+			return false, nil
+		}
+		cmp, err := compareOrdered(l, r)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	}, nil
+}
+
+// compareOrdered compares two numbers or two strings
+func compareOrdered(l, r interface{}) (int, error) {
+	switch lv := l.(type) {
+	case float64:
+		if rv, ok := r.(float64); ok {
+			switch {
+			case lv < rv:
+				return -1, nil
+			case lv > rv:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if rv, ok := r.(string); ok {
+			return strings.Compare(lv, rv), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", jsonType(l), jsonType(r))
+}
+
+func (p *exprParser) parseSum() (exprFunc, error) {
+	left, err := p.parseProduct()
+	for err == nil {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			break
+		}
+		var right exprFunc
+		if right, err = p.parseProduct(); err == nil {
+			left = arithmetic(op, left, right)
+		}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseProduct() (exprFunc, error) {
+	left, err := p.parseUnary()
+	for err == nil {
+		op, ok := p.accept("*", "/")
+		if !ok {
+			break
+		}
+		var right exprFunc
+		if right, err = p.parseUnary(); err == nil {
+			left = arithmetic(op, left, right)
+		}
+	}
+	return left, err
+}
+
+func arithmetic(op string, left, right exprFunc) exprFunc {
+	return func(value interface{}) (interface{}, error) {
+		l, err := left(value)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right(value)
+		if err != nil {
+			return nil, err
+		}
+		if ls, ok := l.(string); ok && op == "+" {
+			if rs, ok := r.(string); ok {
+				return ls + rs, nil
+			}
+		}
+		ln, lok := l.(float64)
+		rn, rok := r.(float64)
+		if !lok || !rok {
+			return nil, fmt.Errorf("cannot apply %s to %s and %s", op, jsonType(l), jsonType(r))
+		}
+		switch op {
+		case "+":
+			return ln + rn, nil
+		case "-":
+			return ln - rn, nil
+		case "*":
+			return ln * rn, nil
+		default:
+			if rn == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return ln / rn, nil
+		}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprFunc, error) {
+	op, ok := p.accept("!", "-")
+	if !ok {
+		return p.parsePostfix()
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if op == "!" {
+		return func(value interface{}) (interface{}, error) {
+			b, err := evalBool(operand, value)
+			return !b, err
+		}, nil
+	}
+	return func(value interface{}) (interface{}, error) {
+		v, err := operand(value)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot negate %s", jsonType(v))
+		}
+		return -n, nil
+	}, nil
+}
+
+// parsePostfix parses a primary expression followed by any number of
+// .name and [index] accessors
+func (p *exprParser) parsePostfix() (exprFunc, error) {
+	fn, err := p.parsePrimary()
+	for err == nil {
+		if _, ok := p.accept("."); ok {
+			t := p.peek()
+			if t.kind != tokenIdent {
+				return nil, fmt.Errorf("expected a member name at offset %d, found %s", t.pos, t)
+			}
+			p.next++
+			fn = member(fn, func(interface{}) (interface{}, error) { return t.text, nil })
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			var index exprFunc
+			if index, err = p.parseOr(); err == nil {
+				err = p.expect("]")
+			}
+			fn = member(fn, index)
+			continue
+		}
+		break
+	}
+	return fn, err
+}
+
+// member looks up an object member by a string key or an array element by
+// a number. Anything that does not exist is null.
+func member(container, key exprFunc) exprFunc {
+	return func(value interface{}) (interface{}, error) {
+		c, err := container(value)
+		if err != nil {
+			return nil, err
+		}
+		k, err := key(value)
+		if err != nil {
+			return nil, err
+		}
+		switch cv := c.(type) {
+		case map[string]interface{}:
+			if name, ok := k.(string); ok {
+				return cv[name], nil
+			}
+		case []interface{}:
+			if n, ok := k.(float64); ok && n >= 0 && n < float64(len(cv)) && n == float64(int(n)) {
+				return cv[int(n)], nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+func (p *exprParser) parsePrimary() (exprFunc, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNumber:
+		p.next++
+		return constant(t.num), nil
+	case tokenString:
+		p.next++
+		return constant(t.text), nil
+	case tokenIdent:
+		p.next++
+		switch t.text {
+		case "value":
+			return func(value interface{}) (interface{}, error) { return value, nil }, nil
+		case "true":
+			return constant(true), nil
+		case "false":
+			return constant(false), nil
+		case "null":
+			return constant(nil), nil
+		}
+		return nil, fmt.Errorf("unknown name %q at offset %d (the input is \"value\")", t.text, t.pos)
+	}
+	if _, ok := p.accept("("); ok {
+		fn, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return fn, p.expect(")")
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+}
+
+func constant(c interface{}) exprFunc {
+	return func(interface{}) (interface{}, error) { return c, nil }
+}
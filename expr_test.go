@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilterExpr(t *testing.T) {
+	people := `[
+		{"name": "Ada", "age": 36, "tags": ["math"]},
+		{"name": "Bob", "age": 17, "tags": []},
+		{"name": "Cy", "age": 52, "tags": ["art", "math"]},
+		{"name": "Di"}
+	]`
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected string
+		wantErr  string
+	}{
+		{name: "guarded comparison", expr: `value.age != null && value.age > 18`, expected: `["Ada","Cy"]`},
+		{name: "or and not", expr: `value.name == "Cy" || !(value.age != null && value.age >= 18)`, expected: `["Bob","Cy","Di"]`},
+		{name: "missing member is null", expr: `value.age == null`, expected: `["Di"]`},
+		{name: "bracket and index access", expr: `value["tags"][0] == 'math'`, expected: `["Ada"]`},
+		{name: "string ordering", expr: `value.name < "C"`, expected: `["Ada","Bob"]`},
+		{name: "arithmetic", expr: `value.age != null && value.age * 2 - 2 == 70`, expected: `["Ada"]`},
+		{name: "map to a value", expr: `value.name + "!"`, expected: `["Ada!","Bob!","Cy!","Di!"]`},
+		{name: "ordering a missing member", expr: `value.age > 18`, expected: `["Ada","Cy"]`},
+		{name: "ordering null is false either way", expr: `value.age <= 18 || 18 > value.age`, expected: `["Bob"]`},
+		{name: "logic on a missing member", expr: `value.tags[1] == "math" || value.retired`, expected: `["Cy"]`},
+		{name: "not a missing member", expr: `!value.retired && value.name != "Bob"`, expected: `["Ada","Cy","Di"]`},
+		{name: "ordering mismatched types", expr: `value.name > 18`, wantErr: "element 0: --expr: cannot compare string with number"},
+		{name: "logic on a non-boolean", expr: `value.name && true`, wantErr: "element 0: --expr: expected a boolean, got string"},
+		{name: "unknown name", expr: `age > 18`, wantErr: `invalid --expr: unknown name "age" at offset 0`},
+		{name: "trailing tokens", expr: `value.age 18`, wantErr: `invalid --expr: unexpected "18" at offset 10`},
+		{name: "unterminated string", expr: `value.name == "Ada`, wantErr: "invalid --expr: unterminated string at offset 14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(people)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := filterExpr(data.([]interface{}), tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("filterExpr() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("filterExpr() error = %v", err)
+			}
+			var names []interface{}
+			for _, element := range result {
+				if person, ok := element.(map[string]interface{}); ok {
+					element = person["name"]
+				}
+				names = append(names, element)
+			}
+			expected, _ := parseJSON(tt.expected)
+			if !reflect.DeepEqual(names, expected) {
+				t.Errorf("filterExpr() = %v, want %s", names, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunExpr(t *testing.T) {
+	input := `{"users": [{"id": 1, "active": true}, {"id": 2, "active": false}, {"id": 3, "active": true}]}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "array", args: []string{"--expr", "value.active", "--path", "/users", "--first", "1", "array", input}, expected: `[{"active":true,"id":1}]` + "\n"},
+		{name: "tondjson", args: []string{"--expr", "value.id", "--path", "/users", "tondjson", input}, expected: "1\n2\n3\n"},
+		{name: "each", args: []string{"--each", "/users", "--expr", "!value.active", "tondjson", input}, expected: `{"active":false,"id":2}` + "\n"},
+		{name: "missing member", args: []string{"--expr", "value.score >= 5", "array", `[{"score": 7}, {"id": 2}, {"score": 3}]`}, expected: `[{"score":7}]` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != 0 {
+				t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern of a .gitignore file
+type ignoreRule struct {
+	// base is the directory holding the .gitignore, relative to the walk
+	// root with forward slashes, or "" for the root itself
+	base string
+	// segments is the pattern split at slashes, without a leading or
+	// trailing slash
+	segments []string
+	// anchored patterns contain a slash before their end and match paths
+	// relative to base; others match a name at any depth below it
+	anchored bool
+	dirOnly  bool
+	negate   bool
+}
+
+// gitignore holds the rules of the .gitignore files found while walking a
+// directory tree, in the order they apply
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// load adds the rules of the .gitignore in dir, if there is one. rel is dir
+// relative to the walk root, with forward slashes.
+func (g *gitignore) load(dir, rel string) error {
+	file, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		rel = ""
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), rel); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+	err = scanner.Err()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// parseIgnoreRule reads one line of a .gitignore in the directory base.
+// Blank lines and comments give no rule.
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	rule.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// ignored reports whether the path rel, relative to the walk root with
+// forward slashes, is ignored. The last matching rule decides, so rules
+// from deeper .gitignore files and negated patterns override earlier ones.
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.matches(rel, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+	if !r.anchored {
+		// Directories above rel that matched were skipped, so only the
+		// last name needs checking
+		return matchSegments(r.segments, []string{path.Base(rel)})
+	}
+	return matchSegments(r.segments, strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments against pattern segments, where "**"
+// stands for any number of segments, or at least one at the end
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(name) > 0
+		}
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
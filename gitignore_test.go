@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGitignoreIgnored(t *testing.T) {
+	var ignore gitignore
+	for _, line := range []string{
+		"# comment",
+		"",
+		"*.tmp.json",
+		"!keep.tmp.json",
+		"build/",
+		"/top.json",
+		"docs/**/draft.json",
+		"cache/**",
+		`\#hash.json`,
+	} {
+		if rule, ok := parseIgnoreRule(line, ""); ok {
+			ignore.rules = append(ignore.rules, rule)
+		}
+	}
+	if rule, ok := parseIgnoreRule("local.json", "sub"); ok {
+		ignore.rules = append(ignore.rules, rule)
+	}
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{path: "a.tmp.json", expected: true},
+		{path: "deep/b.tmp.json", expected: true},
+		{path: "deep/keep.tmp.json", expected: false},
+		{path: "build", isDir: true, expected: true},
+		{path: "build", expected: false},
+		{path: "src/build", isDir: true, expected: true},
+		{path: "top.json", expected: true},
+		{path: "sub/top.json", expected: false},
+		{path: "docs/draft.json", expected: true},
+		{path: "docs/a/b/draft.json", expected: true},
+		{path: "other/draft.json", expected: false},
+		{path: "cache", isDir: true, expected: false},
+		{path: "cache/x.json", expected: true},
+		{path: "#hash.json", expected: true},
+		{path: "sub/local.json", expected: true},
+		{path: "sub/deeper/local.json", expected: true},
+		{path: "local.json", expected: false},
+		{path: "data.json", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ignore.ignored(tt.path, tt.isDir); got != tt.expected {
+				t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindFilesRespectGitignore(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":         "*.tmp.json\nbuild/\n/secrets.json\n",
+		"a.json":             `{}`,
+		"a.tmp.json":         `{}`,
+		"secrets.json":       `{}`,
+		"build/out.json":     `{}`,
+		".git/config.json":   `{}`,
+		"sub/.gitignore":     "local.json\n!keep.tmp.json\n",
+		"sub/b.json":         `{}`,
+		"sub/local.json":     `{}`,
+		"sub/keep.tmp.json":  `{}`,
+		"sub/other.tmp.json": `{}`,
+		"sub/secrets.json":   `{}`,
+		"other/local.json":   `{}`,
+	})
+
+	relative := func(files []string) []string {
+		var rel []string
+		for _, file := range files {
+			r, _ := filepath.Rel(root, file)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		return rel
+	}
+
+	files, err := findFiles(root, ".json", true)
+	if err != nil {
+		t.Fatalf("findFiles() error = %v", err)
+	}
+	expected := []string{"a.json", "other/local.json", "sub/b.json", "sub/keep.tmp.json", "sub/secrets.json"}
+	if got := relative(files); !reflect.DeepEqual(got, expected) {
+		t.Errorf("findFiles() = %v, want %v", got, expected)
+	}
+
+	files, err = findFiles(root, ".json", false)
+	if err != nil {
+		t.Fatalf("findFiles() error = %v", err)
+	}
+	// Everything but .git, which is never walked
+	if len(files) != 10 {
+		t.Errorf("findFiles() without --respect-gitignore = %v, want all 10 files outside .git", relative(files))
+	}
+}
+
+func TestRunRespectGitignore(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":  "broken.json\n",
+		"a.json":      `{"id": 1}`,
+		"broken.json": `{"id": `,
+		"sub/b.json":  `{"id": 2}`,
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-f", "--recursive", "--respect-gitignore", "encode", root}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if expected := `"{\"id\":1}"` + "\n" + `"{\"id\":2}"` + "\n"; stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"-f", "--respect-gitignore", "encode", filepath.Join(root, "a.json")}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("run() without --recursive = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "requires --recursive") {
+		t.Errorf("run() stderr = %q, want the --recursive error", stderr.String())
+	}
+}
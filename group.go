@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// groupBy buckets array elements by the value found at a JSON Pointer within
+// each element. Elements keep their input order inside a bucket; elements
+// where the pointer does not resolve are collected under missingKey.
+func groupBy(array []interface{}, keyPointer, missingKey string) (map[string]interface{}, error) {
+	if _, err := parsePointer(keyPointer); err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]interface{})
+	for _, element := range array {
+		bucket := missingKey
+		if value, err := resolvePointer(element, keyPointer); err == nil {
+			name, err := groupName(value)
+			if err != nil {
+				return nil, err
+			}
+			bucket = name
+		}
+
+		members, _ := groups[bucket].([]interface{})
+		groups[bucket] = append(members, element)
+	}
+	return groups, nil
+}
+
+// groupName turns a key value into a bucket name: strings are used as-is,
+// any other value by its JSON text (e.g. 30, true, null)
+func groupName(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	name, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal group key: %v", err)
+	}
+	return string(name), nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	data, err := parseJSON(`[
+		{"name": "ada", "team": "red", "age": 30},
+		{"name": "bob", "team": "blue", "age": 25},
+		{"name": "cy", "age": 30},
+		{"name": "dee", "team": "red"},
+		"not an object"
+	]`)
+	if err != nil {
+		t.Fatalf("parseJSON() error = %v", err)
+	}
+	array, err := arrayAt(data, "")
+	if err != nil {
+		t.Fatalf("arrayAt() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		key        string
+		missingKey string
+		expected   string
+	}{
+		{
+			name:       "string field",
+			key:        "/team",
+			missingKey: "_missing",
+			expected:   `{"_missing":[{"age":30,"name":"cy"},"not an object"],"blue":[{"age":25,"name":"bob","team":"blue"}],"red":[{"age":30,"name":"ada","team":"red"},{"name":"dee","team":"red"}]}`,
+		},
+		{
+			name:       "number field with custom missing bucket",
+			key:        "/age",
+			missingKey: "none",
+			expected:   `{"25":[{"age":25,"name":"bob","team":"blue"}],"30":[{"age":30,"name":"ada","team":"red"},{"age":30,"name":"cy"}],"none":[{"name":"dee","team":"red"},"not an object"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, err := groupBy(array, tt.key, tt.missingKey)
+			if err != nil {
+				t.Fatalf("groupBy() error = %v", err)
+			}
+			result, err := json.Marshal(groups)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("groupBy() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGroupByInvalidKey(t *testing.T) {
+	if _, err := groupBy([]interface{}{}, "team", "_missing"); err == nil {
+		t.Errorf("groupBy() expected error for pointer without leading slash")
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// typeHistogram counts the values of each JSON type in a document, at any
+// depth. Every type is present in the result, with zero counts included.
+func typeHistogram(value interface{}) map[string]int {
+	counts := map[string]int{"object": 0, "array": 0, "string": 0, "number": 0, "boolean": 0, "null": 0}
+	walkJSON(value, nil, func(path []string, value interface{}) error {
+		counts[jsonType(value)]++
+		return nil
+	})
+	return counts
+}
+
+// writeHistogram writes the type histogram of a JSON document as a JSON
+// object on one line
+func writeHistogram(w io.Writer, jsonStr string) error {
+	data, err := parseJSON(jsonStr)
+	if err != nil {
+		return err
+	}
+	output, err := json.Marshal(typeHistogram(data))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(output))
+	return err
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTypeHistogram(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]int
+	}{
+		{
+			name:     "mixed document",
+			input:    `{"a": [1, 2.5, "x", true, false, null, {}], "b": {"c": [[]], "d": "y"}}`,
+			expected: map[string]int{"object": 3, "array": 3, "string": 2, "number": 2, "boolean": 2, "null": 1},
+		},
+		{
+			name:     "scalar root",
+			input:    `"text"`,
+			expected: map[string]int{"object": 0, "array": 0, "string": 1, "number": 0, "boolean": 0, "null": 0},
+		},
+		{
+			name:     "keys are not counted",
+			input:    `{"k1": null, "k2": null}`,
+			expected: map[string]int{"object": 1, "array": 0, "string": 0, "number": 0, "boolean": 0, "null": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			if got := typeHistogram(data); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("typeHistogram() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteHistogram(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeHistogram(&buf, `[1, "a", null]`); err != nil {
+		t.Fatalf("writeHistogram() error = %v", err)
+	}
+	expected := "{\"array\":1,\"boolean\":0,\"null\":1,\"number\":1,\"object\":0,\"string\":1}\n"
+	if buf.String() != expected {
+		t.Errorf("writeHistogram() = %q, want %q", buf.String(), expected)
+	}
+
+	if err := writeHistogram(&buf, `[1,`); err == nil {
+		t.Error("writeHistogram() expected error for invalid JSON")
+	}
+}
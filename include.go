@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// includeKey is the directive replaced by the contents of a file under
+// --resolve-includes
+const includeKey = "$include"
+
+// resolveIncludes replaces every {"$include": "file.json"} object with the
+// parsed contents of the file. Names are resolved relative to the directory
+// of source, the file the document was read from, or to the working
+// directory when source is empty. Included files may include others,
+// relative to their own directory. Any other keys next to "$include" are
+// merged over the included object.
+func resolveIncludes(value interface{}, source string) (interface{}, error) {
+	if source == "" {
+		return resolveIncludesFrom(value, ".", nil)
+	}
+	path, err := filepath.Abs(source)
+	if err != nil {
+		return nil, err
+	}
+	return resolveIncludesFrom(value, filepath.Dir(path), []string{path})
+}
+
+// resolveIncludesFrom does the work of resolveIncludes; chain lists the
+// files currently being included, outermost first, to detect cycles
+func resolveIncludesFrom(value interface{}, dir string, chain []string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for _, key := range sortedKeys(v) {
+			if key == includeKey {
+				continue
+			}
+			child, err := resolveIncludesFrom(v[key], dir, chain)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = child
+		}
+
+		target, ok := v[includeKey]
+		if !ok {
+			return resolved, nil
+		}
+		name, ok := target.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s must be a file name, got %s", includeKey, jsonType(target))
+		}
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, name)
+		}
+		included, err := includeFile(path, chain)
+		if err != nil {
+			return nil, err
+		}
+		if len(resolved) == 0 {
+			return included, nil
+		}
+		object, ok := included.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: included %s cannot be merged with sibling keys", name, jsonType(included))
+		}
+		for key, child := range resolved {
+			object[key] = child
+		}
+		return object, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, child := range v {
+			child, err := resolveIncludesFrom(child, dir, chain)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = child
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// includeFile reads and resolves one included file
+func includeFile(filename string, chain []string) (interface{}, error) {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	for i, seen := range chain {
+		if seen == path {
+			cycle := append(append([]string{}, chain[i:]...), path)
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading include: %v", err)
+	}
+	data, err := parseJSON(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return resolveIncludesFrom(data, filepath.Dir(path), append(chain, path))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files below a temporary directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	return dir
+}
+
+func TestResolveIncludes(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+		wantErr  string
+	}{
+		{
+			name: "simple include",
+			files: map[string]string{
+				"main.json": `{"db": {"$include": "db.json"}}`,
+				"db.json":   `{"host": "localhost"}`,
+			},
+			expected: `{"db":{"host":"localhost"}}`,
+		},
+		{
+			name: "nested include relative to including file",
+			files: map[string]string{
+				"main.json":         `[{"$include": "conf/a.json"}]`,
+				"conf/a.json":       `{"b": {"$include": "parts/b.json"}}`,
+				"conf/parts/b.json": `"leaf"`,
+			},
+			expected: `[{"b":"leaf"}]`,
+		},
+		{
+			name: "sibling keys override included object",
+			files: map[string]string{
+				"main.json": `{"$include": "base.json", "port": 9090}`,
+				"base.json": `{"host": "a", "port": 80}`,
+			},
+			expected: `{"host":"a","port":9090}`,
+		},
+		{
+			name: "same file included twice is not a cycle",
+			files: map[string]string{
+				"main.json": `[{"$include": "x.json"}, {"$include": "x.json"}]`,
+				"x.json":    `1`,
+			},
+			expected: `[1,1]`,
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"main.json": `{"a": {"$include": "a.json"}}`,
+				"a.json":    `{"b": {"$include": "b.json"}}`,
+				"b.json":    `{"$include": "a.json"}`,
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name: "including the input itself",
+			files: map[string]string{
+				"main.json": `{"self": {"$include": "main.json"}}`,
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"main.json": `{"$include": "missing.json"}`,
+			},
+			wantErr: "reading include",
+		},
+		{
+			name: "non-string directive",
+			files: map[string]string{
+				"main.json": `{"$include": 1}`,
+			},
+			wantErr: "must be a file name",
+		},
+		{
+			name: "sibling keys with non-object include",
+			files: map[string]string{
+				"main.json": `{"$include": "list.json", "extra": true}`,
+				"list.json": `[]`,
+			},
+			wantErr: "cannot be merged",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			source := filepath.Join(dir, "main.json")
+			data, err := parseJSON(tt.files["main.json"])
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+
+			result, err := resolveIncludes(data, source)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveIncludes() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveIncludes() error = %v", err)
+			}
+			output, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("resolveIncludes() = %s, want %s", output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveIncludesAbsolutePath(t *testing.T) {
+	shared := writeFiles(t, map[string]string{"db.json": `{"host": "localhost"}`})
+	dir := writeFiles(t, map[string]string{})
+
+	data := map[string]interface{}{
+		"db": map[string]interface{}{includeKey: filepath.Join(shared, "db.json")},
+	}
+	result, err := resolveIncludes(data, filepath.Join(dir, "main.json"))
+	if err != nil {
+		t.Fatalf("resolveIncludes() error = %v", err)
+	}
+	output, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if expected := `{"db":{"host":"localhost"}}`; string(output) != expected {
+		t.Errorf("resolveIncludes() = %s, want %s", output, expected)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// inputFormats lists the accepted --input-format values
+var inputFormats = []string{"json", "yaml", "toml", "auto"}
+
+// convertInput reads input in the given format and returns it as JSON text.
+// JSON input is returned unchanged. With "auto" the format is sniffed:
+// valid JSON is always read as JSON, then TOML is tried, and YAML, which
+// accepts almost any text, comes last.
+func convertInput(input, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		return input, nil
+	case "yaml":
+		return yamlToJSONText(input)
+	case "toml":
+		data, err := fromTOML(input)
+		if err != nil {
+			return "", err
+		}
+		return marshalConverted(data)
+	case "auto":
+		if json.Valid([]byte(input)) {
+			return input, nil
+		}
+		if data, err := fromTOML(input); err == nil && strings.TrimSpace(input) != "" {
+			return marshalConverted(data)
+		}
+		return yamlToJSONText(input)
+	default:
+		return "", fmt.Errorf("unknown input format %q (want %s)", format, strings.Join(inputFormats, ", "))
+	}
+}
+
+func yamlToJSONText(input string) (string, error) {
+	var data interface{}
+	if err := yaml.Unmarshal([]byte(input), &data); err != nil {
+		return "", fmt.Errorf("invalid YAML input: %v", err)
+	}
+	converted, err := yamlToJSON(data)
+	if err != nil {
+		return "", err
+	}
+	return marshalConverted(converted)
+}
+
+// yamlToJSON turns the maps with non-string keys that YAML allows into
+// JSON objects, using the text of each key. Keys whose text is the same,
+// such as 1 and "1", are reported as an error rather than merged in
+// whatever order the map happens to yield them.
+func yamlToJSON(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for _, key := range sortedKeys(v) {
+			c, err := yamlToJSON(v[key])
+			if err != nil {
+				return nil, err
+			}
+			converted[key] = c
+		}
+		return converted, nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		keys := make(map[string]interface{}, len(v))
+		for key := range v {
+			text := fmt.Sprint(key)
+			if _, exists := keys[text]; exists {
+				return nil, fmt.Errorf("invalid YAML input: keys collide as JSON object keys: %q", text)
+			}
+			keys[text] = key
+		}
+		for _, text := range sortedKeys(keys) {
+			c, err := yamlToJSON(v[keys[text]])
+			if err != nil {
+				return nil, err
+			}
+			converted[text] = c
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, child := range v {
+			c, err := yamlToJSON(child)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = c
+		}
+		return converted, nil
+	default:
+		return value, nil
+	}
+}
+
+func marshalConverted(data interface{}) (string, error) {
+	output, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("input has no JSON equivalent: %v", err)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConvertInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		format   string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "json unchanged",
+			input:    `{"b": 1, "a": [true]}`,
+			format:   "json",
+			expected: `{"b": 1, "a": [true]}`,
+		},
+		{
+			name:     "yaml",
+			input:    "name: api\nports: [80, 443]\ntls:\n  on: true\n",
+			format:   "yaml",
+			expected: `{"name":"api","ports":[80,443],"tls":{"on":true}}`,
+		},
+		{
+			name:     "yaml non-string keys",
+			input:    "1: one\ntrue: yes\n",
+			format:   "yaml",
+			expected: `{"1":"one","true":"yes"}`,
+		},
+		{
+			name:    "yaml keys with the same text",
+			input:   "1: int\n1.0: float\n",
+			format:  "yaml",
+			wantErr: true,
+		},
+		{
+			name:     "toml",
+			input:    "name = \"api\"\n[tls]\non = true\n",
+			format:   "toml",
+			expected: `{"name":"api","tls":{"on":true}}`,
+		},
+		{
+			name:     "auto json",
+			input:    `{"name": "api"}`,
+			format:   "auto",
+			expected: `{"name": "api"}`,
+		},
+		{
+			name:     "auto prefers json for a scalar",
+			input:    `123`,
+			format:   "AUTO",
+			expected: `123`,
+		},
+		{
+			name:     "auto yaml",
+			input:    "name: api\nports:\n  - 80\n  - 443\n",
+			format:   "auto",
+			expected: `{"name":"api","ports":[80,443]}`,
+		},
+		{
+			name:     "auto toml",
+			input:    "name = \"api\"\nports = [80, 443]\n",
+			format:   "auto",
+			expected: `{"name":"api","ports":[80,443]}`,
+		},
+		{name: "invalid yaml", input: "a: [1", format: "yaml", wantErr: true},
+		{name: "invalid toml", input: "a = ", format: "toml", wantErr: true},
+		{name: "unknown format", input: "{}", format: "ini", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertInput(tt.input, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("convertInput() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunInputFormatAuto(t *testing.T) {
+	inputs := map[string]string{
+		"json": `{"name": "api", "port": 80}`,
+		"yaml": "name: api\nport: 80\n",
+		"toml": "name = \"api\"\nport = 80\n",
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run([]string{"--input-format", "auto", "encode", input}, nil, &stdout, &stderr); code != 0 {
+				t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+			}
+			expected := `"{\"name\":\"api\",\"port\":80}"` + "\n"
+			if stdout.String() != expected {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+			}
+		})
+	}
+}
+
+func TestRunInputFormatParseOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		exitCode int
+	}{
+		{name: "yaml", format: "yaml", input: "a: 1", exitCode: 0},
+		{name: "toml", format: "toml", input: "a = 1", exitCode: 0},
+		{name: "auto", format: "auto", input: "a: [1, 2]", exitCode: 0},
+		{name: "invalid yaml", format: "yaml", input: "a: [1", exitCode: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run([]string{"--parse-only", "--input-format", tt.format, "encode", tt.input}, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("run() wrote %q to stdout, want nothing", stdout.String())
+			}
+		})
+	}
+}
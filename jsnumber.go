@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxSafeInteger is JavaScript's Number.MAX_SAFE_INTEGER, 2^53-1, the
+// largest integer beyond which not every integer can be represented
+var maxSafeInteger = big.NewInt(1<<53 - 1)
+
+// formatJSNumber formats a number the way ECMAScript's Number::toString,
+// and so JSON.stringify, does: the shortest digits that round-trip, plain
+// decimal notation for exponents from -7 to 20 and exponent notation
+// outside that range, and "0" for negative zero.
+func formatJSNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+
+	// Shortest round-trip digits as d.ddde±x
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	k := len(digits)
+	n := e + 1 // position of the decimal point relative to the digits
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	expSign := "+"
+	if n-1 < 0 {
+		expSign = "-"
+	}
+	exponent := "e" + expSign + strconv.Itoa(abs(n-1))
+	if k == 1 {
+		return sign + digits + exponent
+	}
+	return sign + digits[:1] + "." + digits[1:] + exponent
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// jsNumbers rewrites every number in a document so that it serializes as
+// JSON.stringify would write it
+func jsNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return json.Number(formatJSNumber(v))
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v
+		}
+		return json.Number(formatJSNumber(f))
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			converted[key] = jsNumbers(child)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, child := range v {
+			converted[i] = jsNumbers(child)
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+// reportUnsafeIntegers warns about every integer in a JSON document outside
+// the JavaScript safe-integer range, which a browser would round. Only
+// numbers written without a fraction or exponent count as integers. Numbers
+// are read exactly, so values already rounded by float64 are still found.
+func reportUnsafeIntegers(w io.Writer, jsonStr string) error {
+	data, err := parseJSONNumbers(jsonStr)
+	if err != nil {
+		return err
+	}
+	return walkJSON(data, nil, func(path []string, value interface{}) error {
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil
+		}
+		if strings.ContainsAny(string(number), ".eE") {
+			return nil
+		}
+		n, ok := new(big.Int).SetString(string(number), 10)
+		if ok && new(big.Int).Abs(n).Cmp(maxSafeInteger) > 0 {
+			fmt.Fprintf(w, "Warning: integer %s at %q is outside the JavaScript safe range of ±(2^53-1)\n", number, formatPointer(path))
+		}
+		return nil
+	})
+}
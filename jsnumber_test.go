@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestFormatJSNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    float64
+		expected string
+	}{
+		{name: "integer", input: 42, expected: "42"},
+		{name: "fraction", input: 0.1, expected: "0.1"},
+		{name: "negative", input: -1.5, expected: "-1.5"},
+		{name: "negative zero", input: math.Copysign(0, -1), expected: "0"},
+		{name: "largest plain integer", input: 1e20, expected: "100000000000000000000"},
+		{name: "1e21 switches to exponent", input: 1e21, expected: "1e+21"},
+		{name: "exponent with fraction", input: 1.5e300, expected: "1.5e+300"},
+		{name: "smallest plain fraction", input: 0.000001, expected: "0.000001"},
+		{name: "5e-7 switches to exponent", input: 5e-7, expected: "5e-7"},
+		{name: "small with fraction", input: -1.25e-10, expected: "-1.25e-10"},
+		{name: "imprecise large integer", input: 12345678901234567890, expected: "12345678901234567000"},
+		{name: "max float", input: math.MaxFloat64, expected: "1.7976931348623157e+308"},
+		{name: "smallest denormal", input: 5e-324, expected: "5e-324"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatJSNumber(tt.input); got != tt.expected {
+				t.Errorf("formatJSNumber(%v) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSNumbers(t *testing.T) {
+	input := map[string]interface{}{
+		"zero":   math.Copysign(0, -1),
+		"list":   []interface{}{1e21, json.Number("5e-7"), json.Number("1.0")},
+		"string": "-0",
+	}
+	result, err := json.Marshal(jsNumbers(input))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	expected := `{"list":[1e+21,5e-7,1],"string":"-0","zero":0}`
+	if string(result) != expected {
+		t.Errorf("jsNumbers() = %s, want %s", result, expected)
+	}
+}
+
+func TestReportUnsafeIntegers(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "safe integers", input: `{"max": 9007199254740991, "min": -9007199254740991, "f": 1.5e300}`},
+		{
+			name:     "unsafe integer",
+			input:    `{"id": 9007199254740993, "n": 1}`,
+			expected: "Warning: integer 9007199254740993 at \"/id\" is outside the JavaScript safe range of ±(2^53-1)\n",
+		},
+		{
+			name:     "negative integer, floats ignored",
+			input:    `[-9007199254740992, 1e20, 9007199254740993.0]`,
+			expected: "Warning: integer -9007199254740992 at \"/0\" is outside the JavaScript safe range of ±(2^53-1)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := reportUnsafeIntegers(&buf, tt.input); err != nil {
+				t.Fatalf("reportUnsafeIntegers() error = %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("reportUnsafeIntegers() = %q, want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
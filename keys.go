@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyCollisionStrategies lists the accepted --key-collision values. When
+// rewritten keys collide, "first" and "last" pick by the sorted order of
+// the original keys, as the input order of object members is not kept.
+var keyCollisionStrategies = []string{"error", "first", "last"}
+
+// validateKeyCollision checks a --key-collision strategy name
+func validateKeyCollision(strategy string) error {
+	for _, s := range keyCollisionStrategies {
+		if strategy == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown --key-collision strategy %q (want %s)", strategy, strings.Join(keyCollisionStrategies, ", "))
+}
+
+// trimKeys removes leading and trailing whitespace from every object key,
+// resolving keys that become identical according to strategy
+func trimKeys(value interface{}, strategy string) (interface{}, error) {
+	return renameKeys(value, strings.TrimSpace, strategy, "after trimming")
+}
+
+// stripKeyPrefix removes everything up to and including the first sep in
+// every object key, so that "ns:field" becomes "field" for sep ":". A
+// leading "@", which marks an XML attribute from fromxml, is kept. Keys
+// that become identical are resolved according to strategy.
+func stripKeyPrefix(value interface{}, sep, strategy string) (interface{}, error) {
+	strip := func(key string) string {
+		marker := ""
+		if strings.HasPrefix(key, "@") {
+			marker, key = "@", key[1:]
+		}
+		if _, rest, found := strings.Cut(key, sep); found {
+			key = rest
+		}
+		return marker + key
+	}
+	return renameKeys(value, strip, strategy, "after stripping prefixes")
+}
+
+// renameKeys rewrites every object key with rename. Keys that map to the
+// same name are an error under the "error" strategy; otherwise the first or
+// last original key in sorted order wins.
+func renameKeys(value interface{}, rename func(string) string, strategy, reason string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		origin := make(map[string]string, len(v))
+		for _, key := range sortedKeys(v) {
+			child, err := renameKeys(v[key], rename, strategy, reason)
+			if err != nil {
+				return nil, err
+			}
+			newKey := rename(key)
+			if previous, exists := origin[newKey]; exists {
+				switch strategy {
+				case "first":
+					continue
+				case "last":
+				default:
+					return nil, fmt.Errorf("keys %q and %q collide %s", previous, key, reason)
+				}
+			}
+			origin[newKey] = key
+			renamed[newKey] = child
+		}
+		return renamed, nil
+	case []interface{}:
+		renamed := make([]interface{}, len(v))
+		for i, child := range v {
+			child, err := renameKeys(child, rename, strategy, reason)
+			if err != nil {
+				return nil, err
+			}
+			renamed[i] = child
+		}
+		return renamed, nil
+	default:
+		return value, nil
+	}
+}
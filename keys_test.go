@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrimKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		input    interface{}
+		expected interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "padded keys",
+			strategy: "error",
+			input:    map[string]interface{}{" key ": 1.0, "\tother\n": "x", "plain": true},
+			expected: map[string]interface{}{"key": 1.0, "other": "x", "plain": true},
+		},
+		{
+			name:     "nested objects and arrays",
+			strategy: "error",
+			input:    map[string]interface{}{"list ": []interface{}{map[string]interface{}{" a": " value "}}},
+			expected: map[string]interface{}{"list": []interface{}{map[string]interface{}{"a": " value "}}},
+		},
+		{
+			name:     "collision is an error",
+			strategy: "error",
+			input:    map[string]interface{}{"id": 1.0, " id ": 2.0},
+			wantErr:  true,
+		},
+		{
+			name:     "collision keeps first sorted key",
+			strategy: "first",
+			input:    map[string]interface{}{"id": 1.0, " id ": 2.0},
+			expected: map[string]interface{}{"id": 2.0},
+		},
+		{
+			name:     "collision keeps last sorted key",
+			strategy: "last",
+			input:    map[string]interface{}{"id": 1.0, " id ": 2.0},
+			expected: map[string]interface{}{"id": 1.0},
+		},
+		{
+			name:     "scalar root unchanged",
+			strategy: "error",
+			input:    " text ",
+			expected: " text ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := trimKeys(tt.input, tt.strategy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("trimKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("trimKeys() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStripKeyPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		sep      string
+		strategy string
+		input    interface{}
+		expected interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "namespaced keys",
+			sep:      ":",
+			strategy: "error",
+			input:    map[string]interface{}{"ns:field": 1.0, "plain": 2.0, "a:b:c": 3.0},
+			expected: map[string]interface{}{"field": 1.0, "plain": 2.0, "b:c": 3.0},
+		},
+		{
+			name:     "nested and attribute keys",
+			sep:      ":",
+			strategy: "error",
+			input:    map[string]interface{}{"x:list": []interface{}{map[string]interface{}{"@xlink:href": "/a", "@id": "1"}}},
+			expected: map[string]interface{}{"list": []interface{}{map[string]interface{}{"@href": "/a", "@id": "1"}}},
+		},
+		{
+			name:     "multi-character separator",
+			sep:      "__",
+			strategy: "error",
+			input:    map[string]interface{}{"app__port": 80.0, "app_name": "x"},
+			expected: map[string]interface{}{"port": 80.0, "app_name": "x"},
+		},
+		{
+			name:     "collision is an error",
+			sep:      ":",
+			strategy: "error",
+			input:    map[string]interface{}{"a:id": 1.0, "b:id": 2.0},
+			wantErr:  true,
+		},
+		{
+			name:     "collision keeps first sorted key",
+			sep:      ":",
+			strategy: "first",
+			input:    map[string]interface{}{"a:id": 1.0, "b:id": 2.0, "id": 3.0},
+			expected: map[string]interface{}{"id": 1.0},
+		},
+		{
+			name:     "collision keeps last sorted key",
+			sep:      ":",
+			strategy: "last",
+			input:    map[string]interface{}{"a:id": 1.0, "b:id": 2.0, "id": 3.0},
+			expected: map[string]interface{}{"id": 3.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := stripKeyPrefix(tt.input, tt.sep, tt.strategy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("stripKeyPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("stripKeyPrefix() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateKeyCollision(t *testing.T) {
+	for _, strategy := range keyCollisionStrategies {
+		if err := validateKeyCollision(strategy); err != nil {
+			t.Errorf("validateKeyCollision(%q) error = %v", strategy, err)
+		}
+	}
+	if err := validateKeyCollision("merge"); err == nil {
+		t.Error("validateKeyCollision(\"merge\") expected error")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "fmt"
+
+// limitOptions holds the structural limits an input document must respect
+type limitOptions struct {
+	maxArrayLength int // zero when unlimited
+	maxNodes       int // zero when unlimited
+	strictNumbers  bool
+}
+
+// parseDocument parses a JSON string and enforces the configured limits
+func parseDocument(jsonStr string, limits limitOptions) (interface{}, error) {
+	data, err := parseJSON(jsonStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLimits(data, limits); err != nil {
+		return nil, err
+	}
+	if limits.strictNumbers {
+		if err := checkStrictNumbers(jsonStr); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// checkLimits reports the first place a document exceeds a configured limit
+func checkLimits(data interface{}, limits limitOptions) error {
+	if limits.maxArrayLength <= 0 && limits.maxNodes <= 0 {
+		return nil
+	}
+	nodes := 0
+	return walkJSON(data, nil, func(path []string, value interface{}) error {
+		nodes++
+		if limits.maxNodes > 0 && nodes > limits.maxNodes {
+			return fmt.Errorf("document has more than %d values (reached at %q), exceeding --max-nodes %d", limits.maxNodes, formatPointer(path), limits.maxNodes)
+		}
+		if array, ok := value.([]interface{}); ok && limits.maxArrayLength > 0 && len(array) > limits.maxArrayLength {
+			return fmt.Errorf("array at %q has %d elements, exceeding --max-array-length %d", formatPointer(path), len(array), limits.maxArrayLength)
+		}
+		return nil
+	})
+}
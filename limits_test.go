@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxArrayLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		limit   int
+		wantErr string
+	}{
+		{
+			name:  "unlimited by default",
+			input: `{"items": [1, 2, 3, 4, 5]}`,
+		},
+		{
+			name:  "arrays within the limit",
+			input: `{"a": [1, 2, 3], "b": [[1, 2], [3]]}`,
+			limit: 3,
+		},
+		{
+			name:    "long array nested inside an object",
+			input:   `{"data": {"name": "list", "items": [1, 2, 3, 4]}}`,
+			limit:   3,
+			wantErr: `array at "/data/items" has 4 elements`,
+		},
+		{
+			name:    "long array nested inside an array",
+			input:   `[[1], [1, 2, 3]]`,
+			limit:   2,
+			wantErr: `array at "/1" has 3 elements`,
+		},
+		{
+			name:    "long root array",
+			input:   `[1, 2, 3]`,
+			limit:   2,
+			wantErr: `array at "" has 3 elements`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDocument(tt.input, limitOptions{maxArrayLength: tt.limit})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("parseDocument() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseDocument() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaxNodes(t *testing.T) {
+	// 1 object + 1 array + 3 numbers + 1 string = 6 values
+	input := `{"a": [1, 2, 3], "b": "x"}`
+
+	tests := []struct {
+		name    string
+		limit   int
+		wantErr string
+	}{
+		{name: "unlimited by default"},
+		{name: "exactly at the limit", limit: 6},
+		{name: "just over the limit", limit: 5, wantErr: `document has more than 5 values (reached at "/b")`},
+		{name: "far over the limit", limit: 1, wantErr: `more than 1 values (reached at "/a")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDocument(input, limitOptions{maxNodes: tt.limit})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("parseDocument() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseDocument() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStrictNumbers(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "precision-safe numbers", input: `{"price": 19.99, "qty": 3, "rate": 0.1, "big": 9007199254740992, "exp": 1e2, "one": 1.0}`},
+		{name: "20-digit integer", input: `{"amount": 12345678901234567890}`, wantErr: `number 12345678901234567890 at "/amount"`},
+		{name: "just past 2^53", input: `[9007199254740993]`, wantErr: `number 9007199254740993 at "/0"`},
+		{name: "too many fraction digits", input: `0.12345678901234567890123`, wantErr: `number 0.12345678901234567890123 at ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseDocument(tt.input, limitOptions{}); err != nil {
+				t.Fatalf("parseDocument() without --strict-numbers error = %v", err)
+			}
+			_, err := parseDocument(tt.input, limitOptions{strictNumbers: true})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("parseDocument() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseDocument() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"flag"
@@ -9,20 +11,249 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	usage = `jsonencoder - A CLI tool to encode and decode JSON strings
 
 Usage:
-  %s [options] <command> <input>
+  %s [options] <command> [<input>]
 
 Commands:
   encode    Encode JSON (escape for embedding)
   decode    Decode JSON (unescape)
+  escape    Output arbitrary text as a JSON string literal
+  unescape  Resolve the escapes in a JSON string literal and output the raw text
+  encoding  Report the text encoding of the raw input without transforming it
+  array     Output an array, optionally sliced with --first/--last
+  shuffle   Output an array in random order (reproducible with --seed)
+  group-by  Group an array of objects by the value at --key
+  split-by  Write each group of an array of objects by --key to its own file in --out-dir
+  stats     Report min, max, mean, median and percentiles of the numbers at --path
+  project   Extract the --fields of a document into a flat object
+  dump      List every leaf value with its JSON Pointer, one per line
+  pretty    Indent a JSON document by --indent, keeping key order
+  exists    Exit with code 0 if --path resolves to a value and 3 if not, printing nothing
+  conform   List where the structure differs from the --sample document
+  tondjson  Write each element of an array as its own line (NDJSON)
+  fromndjson Collect newline-delimited JSON values into an array
+  apply-patch <target> <patch>
+            Apply an RFC 7386 JSON Merge Patch to the target document
+  apply-jsonpatch <target> <patch>
+            Apply an RFC 6902 JSON Patch (array of operations) to the target
+  gen-patch <from> <to>
+            Compute an RFC 6902 JSON Patch that turns one document into another
+  fromtoml  Convert a TOML document to JSON
+  totoml    Convert a JSON object to TOML
+  fromxml   Convert an XML document to JSON
+  toxml     Convert a JSON object with a single root key to XML
+  version   Print the tool and Go versions
 
 Options:
   -f, --file    Read input from file instead of command line argument
+                Without an input argument, or with "-", input is read from stdin
+  --recursive   With -f, run the command on every matching file under a directory
+  --ext <ext>   File extension --recursive looks for (default ".json")
+  --respect-gitignore
+                Skip files and directories matched by .gitignore files (--recursive)
+  --config <file>
+                Read default option values from a JSON or YAML file
+  --seed N      Seed the random number generator so randomized output is reproducible
+  --fail-on-warnings
+                Exit with code 4 if any warning was printed
+  --root-key <name>
+                Wrap a non-object root value as {"<name>": <value>} when encoding
+  --force       Apply --root-key even when the root is already an object
+  --add-field <pointer>=<value>
+                Set the value at a JSON Pointer, creating missing objects; the value is
+                read as JSON, or else as a string; may be repeated (encode)
+  --clamp-depth N
+                Replace objects and arrays nested deeper than N levels with a placeholder
+  --clamp-placeholder <json>
+                JSON value that replaces clamped content (default "…")
+  --null-to <json>
+                Replace every null value with the given JSON value (encode)
+  --empty-policy <mode>
+                Convert empty strings, arrays and objects to null (empty-to-null) or
+                null to empty values (null-to-empty) (encode)
+  --empty-type <type>
+                Empty value null becomes with null-to-empty: string, array or object
+  --trim-trailing-nulls
+                Remove null elements from the end of arrays (encode)
+  --trim-all-nulls
+                Remove every null element from arrays (encode)
+  --unicode-normalize <form>
+                Apply Unicode normalization (nfc, nfd, nfkc or nfkd) to string values
+  --normalize-keys
+                Also apply --unicode-normalize to object keys
+  --normalize-timestamps <layout>
+                Rewrite strings that parse as timestamps in rfc3339, rfc3339nano,
+                rfc1123, date or a Go layout, in UTC (encode)
+  --timestamp-keys <keys>
+                Comma-separated key names to limit --normalize-timestamps to
+  --coerce-booleans
+                Turn the strings true, yes, on, 1, false, no, off and 0, in any case,
+                into booleans (encode)
+  --boolean-keys <keys>
+                Comma-separated key names to limit --coerce-booleans to
+  --resolve-includes
+                Replace {"$include": "file"} objects with the file contents (encode)
+  --resolve-refs
+                Replace {"$ref": "#/pointer"} objects with the value they point to (encode)
+  --trim-keys   Trim leading and trailing whitespace from object keys
+  --strip-key-prefix <sep>
+                Remove everything up to and including the first sep in object keys,
+                e.g. "ns:field" to "field" with ":" (encode, fromxml)
+  --envsubst-keys
+                Expand ${VAR} references in object keys from the environment (encode)
+  --overwrite   Let keys expanded by --envsubst-keys replace existing keys
+  --strip-control
+                Remove ASCII control characters from string values (encode)
+  --replace-control <text>
+                Replace ASCII control characters in string values with text (encode)
+  --include-keys
+                Also apply --strip-control or --replace-control to object keys
+  --key-collision <strategy>
+                Resolve keys that collide after rewriting: error (default), first or last
+  --sort-objects-by <order>
+                Order object members by key (default) or by serialized value (encode)
+  --collation <locale>
+                Compare key names by the collation rules of a locale, such as "de" or
+                "sv", instead of by code point (encode)
+  --js-numbers  Write numbers exactly as JavaScript's JSON.stringify would (encode)
+  --pipeline <stages>
+                Comma-separated transform stages to apply in order (encode)
+  --max-array-length N
+                Reject documents containing an array with more than N elements
+  --max-nodes N Reject documents containing more than N values in total
+  --strict-numbers
+                Reject documents containing numbers that would lose precision as float64
+  --path <pointer>
+                JSON Pointer addressing the value a command operates on; for stats,
+                relative to each element of the array
+  --treat-null-as-absent
+                Count a null value as missing (exists)
+  --first N     Keep only the first N array elements (array)
+  --last N      Keep only the last N array elements (array)
+  --key <pointer>
+                Pointer, relative to each element, of the value to group by (group-by,
+                split-by)
+  --missing-key <name>
+                Group name for elements without the --key value (default "_missing")
+  --out-dir <dir>
+                Directory to write one file per group to (split-by)
+  --fields <pointers>
+                Comma-separated JSON Pointers of the fields to extract (project)
+  --omit-missing
+                Leave out missing fields instead of setting them to null (project)
+  --kv          Print leaves as "path = value" lines (dump)
+  --separator <text>
+                Separator between path and value with --kv (default "=")
+  --indent <indent>
+                Number of spaces, or spaces and tabs such as "\t", to indent each
+                level by (default 2) (pretty)
+  --inline-arrays
+                Keep arrays holding only scalars on one line (pretty)
+  --inline-array-max N
+                Wrap arrays with more than N elements despite --inline-arrays (pretty)
+  --collapse-below N
+                Summarize objects and arrays N levels below the root as {…3 keys}
+                or […10 items] (pretty)
+  --indent-levels N
+                Write objects and arrays N levels below the root on one line (pretty)
+  --sample <file>
+                Sample document whose key sets and types to compare against (conform)
+  --ignore-path <pointer>
+                Leave differences at and below the pointer out of the patch; may be
+                repeated (gen-patch)
+  --float-tolerance <epsilon>
+                Treat numbers that are not both integers as equal when they differ
+                by at most epsilon (gen-patch)
+  --diff-format <patch|json|text>
+                Write the differences as an RFC 6902 patch (default), a JSON list
+                of old and new values, or text lines (gen-patch)
+  --skip-invalid
+                Skip lines that are not valid JSON instead of failing (fromndjson)
+  --each <pointer>
+                Stream the array at the pointer, writing each element's result as it
+                is read (tondjson, project)
+  --expr <expression>
+                Filter or map each array element, e.g. 'value.age > 18'
+                (array, tondjson, --each)
+  --sample-rate <rate>
+                Pass each --each element through with this probability, between 0
+                and 1; --seed makes the sample reproducible
+  --sse         Write each --each result as a Server-Sent Events message
+  --sse-id      Start each --sse message with an id: line holding the element's index
+  --line-buffered
+                Write each --each result as soon as it is ready instead of in blocks
+  --summary     Write the counts of inputs processed, failed and skipped, the bytes read
+                and written, and the elapsed time to stderr (--recursive, --each)
+  --tee <file>  Also write the output to the given file
+  --gzip        Compress the --tee file with gzip, adding ".gz" to its name
+  --emit-bom    Start the --tee file with a UTF-8 byte order mark
+  --emit-bom-stdout
+                Start stdout with a UTF-8 byte order mark
+  --checksum <sha256|sha512>
+                Also write the digest of the --tee file to <file>.sha256 or <file>.sha512
+  --max-output N
+                Write at most N bytes to stdout, reporting on stderr when output was cut
+  --prefix <text>
+                Text to prepend to every output line
+  --suffix <text>
+                Text to append to every output line
+  --allow-bare-string
+                Encode input that is not valid JSON as a plain string (encode)
+  --input-format <format>
+                Read the input document as json (default), yaml, toml or auto to
+                detect the format
+  --coerce-input
+                Read input that is not JSON as a JSON string, unless it starts like an
+                object or array
+  --best-effort Keep the complete values before a syntax error in invalid input,
+                with a warning
+  --multipart <field>
+                Write the minified JSON as a multipart/form-data body with one part
+                named field, preceded by its Content-Type header (encode)
+  --verify      Decode the encoded output again and check it matches the input (encode)
+  --report-escapes
+                List the characters escaped by encode on stderr (encode)
+  --reformat <style>
+                Re-serialize decoded JSON as compact, pretty or sorted (decode)
+  --explain     Report the encoded and decoded lengths, the number of escapes resolved
+                and whether the inner JSON is valid on stderr (decode)
+  --report-dup-keys
+                List objects containing duplicate keys on stderr
+  --histogram   Write the count of values of each JSON type to stderr as a JSON object
+  --find-duplicates
+                List repeated identical objects and arrays, with their size, on stderr
+  --scan-secrets
+                Warn on stderr about string values that look like passwords, keys or
+                tokens, without changing the output
+  --warn-unsafe-integers
+                Warn on stderr about integers outside JavaScript's safe range of
+                ±(2^53-1), without changing the output
+  --preserve-escapes
+                Keep optional escapes like \/ and \u0041 in decoded strings (decode)
+  --unescape-ascii
+                Write \uXXXX escapes of printable ASCII characters in decoded strings
+                as the characters themselves (decode)
+  --strict-utf8-decode
+                Fail with the offset of an unpaired surrogate escape or invalid byte
+                instead of decoding it to U+FFFD (decode)
+  --parse-only  Parse the input and discard it without writing any output
+  --measure     Report the parse time on stderr (with --parse-only)
+  --repeat N    Run the command N times, writing the result once and the total and
+                per-run time to stderr
+  --preview-bytes N
+                Show the first and last N bytes of input that fails to parse
+  --assert-nonempty
+                Exit with code 3 if the value is an empty object, array or string
+  --assert-type <type>
+                Exit with code 3 unless the value is an object, array, string,
+                number, boolean or null
+  --version     Print the tool and Go versions
   -h, --help    Show this help message
 
 Examples:
@@ -30,30 +261,40 @@ Examples:
   %s decode '"{\"key\": \"value\"}"'
   %s encode -f input.json
   %s decode -f encoded.json
+  curl -s https://example.com/data.json | %s encode
 `
 )
 
 func main() {
-	var fileInput bool
-	var base64Flag bool
-	flag.BoolVar(&fileInput, "f", false, "Read input from file")
-	flag.BoolVar(&fileInput, "file", false, "Read input from file")
-	flag.BoolVar(&base64Flag, "base64", false, "Base64 encode/decode output/input")
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
 
-	flag.Usage = func() {
-		progName := os.Args[0]
-		fmt.Fprintf(os.Stderr, usage, progName, progName, progName, progName, progName)
+// run executes a command line and returns the process exit code. Input is
+// read from stdin when no input argument, or "-", is given; stdin may be nil
+// when there is none. Results are written to stdout and everything else to
+// stderr.
+func run(arguments []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var opts options
+	fs, args, err := parseArgs(arguments, &opts, stderr)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		return 2
 	}
+	warnings := &warningCounter{Writer: stderr}
+	stderr = warnings
 
-	flag.Parse()
+	if opts.version || (len(args) > 0 && strings.ToLower(args[0]) == "version") {
+		writeVersion(stdout)
+		return 0
+	}
 
-	args := flag.Args()
-	if len(args) < 1 || (len(args) < 2 && !fileInput) {
-		flag.Usage()
-		os.Exit(1)
+	if len(args) < 1 || (len(args) < 2 && !opts.fileInput && !readableStdin(stdin)) {
+		fs.Usage()
+		return 1
 	}
 
-	command := args[0]
 	var input string
 
 	if len(args) > 1 {
@@ -61,58 +302,1066 @@ func main() {
 	}
 
 	var jsonData string
-	var err error
 
-	if fileInput {
+	if input == "-" || (len(args) < 2 && !opts.fileInput) {
+		if opts.set["each"] && !opts.parseOnly && !opts.set["repeat"] && stdin != nil {
+			// --each streams stdin like a file instead of reading it whole
+			opts.eachInput = stdin
+		} else {
+			jsonData, err = readFromStdin(stdin, !rawInputCommands[strings.ToLower(args[0])])
+			if err != nil {
+				fmt.Fprintf(stderr, "Error reading stdin: %v\n", err)
+				return 1
+			}
+		}
+		// Commands see the data as if it had been given as the argument,
+		// while -f still applies to a second input
+		opts.stdinInput = true
+		args = append([]string{args[0], jsonData}, args[min(len(args), 2):]...)
+	} else if opts.fileInput {
 		if input == "" {
-			fmt.Fprintf(os.Stderr, "Error: file name required when using -f flag\n")
-			os.Exit(1)
+			fmt.Fprintf(stderr, "Error: file name required when using -f flag\n")
+			return 1
 		}
-		jsonData, err = readFromFile(input)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-			os.Exit(1)
+		opts.transforms.source = input
+		// --each streams the file instead of reading it whole, and with
+		// --recursive the input is a directory
+		if (!opts.set["each"] || opts.parseOnly) && !opts.recursive {
+			jsonData, err = readFromFile(input)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error reading file: %v\n", err)
+				return 1
+			}
 		}
 	} else {
 		if input == "" {
-			fmt.Fprintf(os.Stderr, "Error: JSON input required\n")
-			os.Exit(1)
+			fmt.Fprintf(stderr, "Error: JSON input required\n")
+			return 1
 		}
 		jsonData = input
 	}
 
+	var files []string
+	if opts.recursive {
+		if !opts.fileInput {
+			fmt.Fprintf(stderr, "Error: --recursive requires -f\n")
+			return 1
+		}
+		if opts.stdinInput {
+			fmt.Fprintf(stderr, "Error: --recursive needs a directory, not stdin\n")
+			return 1
+		}
+		files, err = findFiles(input, opts.ext, opts.gitignore)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading directory: %v\n", err)
+			return 1
+		}
+	}
+
+	if opts.set["seed"] {
+		seedRandom(opts.seed)
+	}
+
+	if err := opts.asserts.validate(); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if opts.repeat < 1 {
+		fmt.Fprintf(stderr, "Error: --repeat must be at least 1\n")
+		return 1
+	}
+	if opts.gitignore && !opts.recursive {
+		fmt.Fprintf(stderr, "Error: --respect-gitignore requires --recursive\n")
+		return 1
+	}
+	if opts.set["repeat"] && opts.recursive {
+		fmt.Fprintf(stderr, "Error: --repeat cannot be combined with --recursive\n")
+		return 1
+	}
+
+	if opts.set["sample-rate"] {
+		if !opts.set["each"] {
+			fmt.Fprintf(stderr, "Error: --sample-rate requires --each\n")
+			return 1
+		}
+		if opts.sampleRate <= 0 || opts.sampleRate > 1 {
+			fmt.Fprintf(stderr, "Error: --sample-rate must be greater than 0 and at most 1\n")
+			return 1
+		}
+	}
+
+	if opts.lineBuffered && !opts.set["each"] {
+		fmt.Fprintf(stderr, "Error: --line-buffered requires --each\n")
+		return 1
+	}
+	if opts.sse && !opts.set["each"] {
+		fmt.Fprintf(stderr, "Error: --sse requires --each\n")
+		return 1
+	}
+	if opts.sseID && !opts.sse {
+		fmt.Fprintf(stderr, "Error: --sse-id requires --sse\n")
+		return 1
+	}
+
+	if opts.parseOnly && opts.set["each"] {
+		fmt.Fprintf(stderr, "Error: --each cannot be combined with --parse-only\n")
+		return 1
+	}
+
+	var summaryOut *countingWriter
+	if opts.summary {
+		if !opts.recursive && !opts.set["each"] {
+			fmt.Fprintf(stderr, "Error: --summary requires --recursive or --each\n")
+			return 1
+		}
+		if opts.set["repeat"] {
+			fmt.Fprintf(stderr, "Error: --summary cannot be combined with --repeat\n")
+			return 1
+		}
+		opts.batch = &batchSummary{start: time.Now()}
+		summaryOut = &countingWriter{Writer: stdout}
+		stdout = summaryOut
+	}
+
+	if opts.emitBOMStdout {
+		stdout = &bomWriter{Writer: stdout}
+	}
+
+	var out io.Writer = stdout
+	var capped *capWriter
+	if opts.maxOutput > 0 {
+		capped = newCapWriter(stdout, opts.maxOutput)
+		out = capped
+	}
+	if opts.gzip && opts.teeFile == "" {
+		fmt.Fprintf(stderr, "Error: --gzip requires --tee\n")
+		return 1
+	}
+	if opts.emitBOM && opts.teeFile == "" {
+		fmt.Fprintf(stderr, "Error: --emit-bom requires --tee\n")
+		return 1
+	}
+	if opts.checksum != "" {
+		if opts.teeFile == "" {
+			fmt.Fprintf(stderr, "Error: --checksum requires --tee\n")
+			return 1
+		}
+		if opts.checksum, err = parseChecksum(opts.checksum); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	var tee io.Closer
+	if opts.teeFile != "" {
+		// A --tee file that cannot be written is a warning, so that the
+		// output still reaches stdout
+		teeOut, file, err := openTee(out, opts.teeFile, opts.gzip, opts.emitBOM, opts.checksum)
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: not writing the --tee file: %v\n", err)
+		} else {
+			tee = file
+			out = teeOut
+		}
+	}
+	if opts.prefix != "" || opts.suffix != "" {
+		out = newLineWrapper(out, opts.prefix, opts.suffix)
+	}
+
+	var code int
+	var outErr error
+	switch {
+	case opts.recursive:
+		code, outErr = runRecursive(fs, args, files, opts, out, stderr)
+	case opts.set["repeat"]:
+		code, outErr = repeatCommand(opts.repeat, out, stderr, func(out, stderr io.Writer) (int, error) {
+			return runCommand(fs, args, jsonData, opts, out, stderr)
+		})
+	default:
+		code, outErr = runCommand(fs, args, jsonData, opts, out, stderr)
+	}
+	if tee != nil {
+		if err := tee.Close(); err != nil {
+			fmt.Fprintf(stderr, "Warning: writing the --tee file: %v\n", err)
+		}
+	}
+	if opts.batch != nil {
+		opts.batch.bytesOut = summaryOut.n
+		opts.batch.write(stderr)
+	}
+	if code != 0 {
+		return code
+	}
+	return finishOutput(outErr, capped, opts, warnings)
+}
+
+// runRecursive runs the command on each file found by --recursive in turn,
+// as if it had been given with -f, stopping at the first that fails. It
+// returns like runCommand. The results are held back until every file has
+// succeeded, so that a failure leaves stdout empty as it does for a single
+// input; only --each writes them as it goes.
+func runRecursive(fs *flag.FlagSet, args, files []string, opts options, out, stderr io.Writer) (int, error) {
+	var results bytes.Buffer
+	target := io.Writer(&results)
+	if opts.set["each"] {
+		target = out
+	}
+	for i, file := range files {
+		fileArgs := append([]string{args[0], file}, args[2:]...)
+		fileOpts := opts
+		fileOpts.transforms.source = file
+		// The files are the inputs --summary counts, not --each elements
+		fileOpts.batch = nil
+		if opts.batch != nil {
+			if info, err := os.Stat(file); err == nil {
+				opts.batch.bytesIn += info.Size()
+			}
+		}
+
+		var jsonData string
+		if !opts.set["each"] {
+			var err error
+			if jsonData, err = readFromFile(file); err != nil {
+				fmt.Fprintf(stderr, "Error reading file: %v\n", err)
+				return 1, nil
+			}
+		}
+		code, outErr := runCommand(fs, fileArgs, jsonData, fileOpts, target, stderr)
+		if opts.batch != nil {
+			opts.batch.processed++
+			if code != 0 {
+				opts.batch.failed++
+				opts.batch.skipped += len(files) - i - 1
+			}
+		}
+		if code == 0 && outErr == nil {
+			continue
+		}
+		if code != 0 {
+			fmt.Fprintf(stderr, "Error: stopped at %s\n", file)
+		}
+		return code, outErr
+	}
+	_, err := results.WriteTo(out)
+	return 0, err
+}
+
+// runCommand runs the command in args on one input, writing the result to
+// out. It returns a non-zero exit code when the command fails, or else the
+// error from writing the output, if any.
+func runCommand(fs *flag.FlagSet, args []string, jsonData string, opts options, out, stderr io.Writer) (int, error) {
+	command := args[0]
+	var input string
+	if len(args) > 1 {
+		input = args[1]
+	}
+
+	if opts.set["each"] && !opts.parseOnly {
+		return runEach(command, input, opts, out, stderr), nil
+	}
+
+	start := time.Now()
+	raw := jsonData
+	jsonData, err := prepareInput(command, jsonData, opts, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1, nil
+	}
+	if opts.parseOnly {
+		return runParseOnly(fs, args, jsonData, opts, stderr, start, len(raw)), nil
+	}
+
+	if opts.reportDupKeys && strings.ToLower(command) != "decode" {
+		// Input that is not JSON is left for the command itself to reject
+		reportDuplicateKeys(stderr, jsonData)
+	}
+	if opts.histogram && strings.ToLower(command) != "decode" {
+		writeHistogram(stderr, jsonData)
+	}
+	if opts.findDuplicates && strings.ToLower(command) != "decode" {
+		reportDuplicateStructures(stderr, jsonData)
+	}
+	if opts.scanSecrets && strings.ToLower(command) != "decode" {
+		reportSecrets(stderr, jsonData)
+	}
+	if opts.warnUnsafeInts && strings.ToLower(command) != "decode" {
+		reportUnsafeIntegers(stderr, jsonData)
+	}
+
+	var outErr error
 	switch strings.ToLower(command) {
 	case "encode":
-		result, err := encodeJSON(jsonData)
+		data, err := parseEncodeInput(jsonData, opts.limits, opts.allowBareString)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error encoding JSON: %v\n", err)
+			return 1, nil
+		}
+		if err := checkAssertions(data, opts.asserts); err != nil {
+			fmt.Fprintf(stderr, "Assertion failed: %v\n", err)
+			return exitAssertionFailed, nil
+		}
+		data, err = applyTransforms(data, opts.transforms)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error encoding JSON: %v\n", err)
+			return 1, nil
+		}
+		data, err = sortObjectsBy(data, opts.sortObjectsBy, opts.collation)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error encoding JSON: %v\n", err)
+			return 1, nil
+		}
+		result, err := encodeValue(data)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintf(stderr, "Error encoding JSON: %v\n", err)
+			return 1, nil
 		}
-		if base64Flag {
-			result = base64.StdEncoding.EncodeToString([]byte(result))
+		if opts.verify {
+			if err := verifyEncodedInput(jsonData, result, opts.transforms); err != nil {
+				fmt.Fprintf(stderr, "Error encoding JSON: %v\n", err)
+				return 1, nil
+			}
+		}
+		if opts.reportEscapes {
+			writeEscapeReport(stderr, countEscapes(result))
+		}
+		if opts.multipart != "" {
+			body, err := multipartBody(opts.multipart, data)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error encoding JSON: %v\n", err)
+				return 1, nil
+			}
+			_, outErr = io.WriteString(out, body)
+		} else {
+			if opts.base64 {
+				result = base64.StdEncoding.EncodeToString([]byte(result))
+			}
+			outErr = writeOutput(out, result)
 		}
-		fmt.Println(result)
 	case "decode":
-		inputToDecode := jsonData
-		if base64Flag {
-			decodedBytes, err := base64.StdEncoding.DecodeString(jsonData)
+		if opts.explain {
+			// Explained before decoding so that failures are explained too
+			if explanation, err := explainDecode(jsonData, opts.base64); err == nil {
+				writeDecodeExplanation(stderr, explanation)
+			}
+		}
+		result, err := decodeInput(jsonData, opts)
+		if err != nil {
+			err = withInputPreview(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error %v\n", err)
+			return 1, nil
+		}
+		if opts.reportDupKeys {
+			reportDuplicateKeys(stderr, result)
+		}
+		if opts.histogram {
+			writeHistogram(stderr, result)
+		}
+		if opts.findDuplicates {
+			reportDuplicateStructures(stderr, result)
+		}
+		if opts.scanSecrets {
+			reportSecrets(stderr, result)
+		}
+		if opts.warnUnsafeInts {
+			reportUnsafeIntegers(stderr, result)
+		}
+		if opts.asserts.enabled() {
+			decoded, err := parseJSON(result)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error decoding JSON: %v\n", err)
+				return 1, nil
+			}
+			if err := checkAssertions(decoded, opts.asserts); err != nil {
+				fmt.Fprintf(stderr, "Assertion failed: %v\n", err)
+				return exitAssertionFailed, nil
+			}
+		}
+		if opts.reformat != "" {
+			result, err = reformatJSON(result, opts.reformat)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error decoding JSON: %v\n", err)
+				return 1, nil
+			}
+		}
+		if opts.unescapeASCII {
+			result = unescapeASCII(result)
+		}
+		outErr = writeOutput(out, result)
+	case "escape":
+		result, err := escapeString(jsonData)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = writeOutput(out, result)
+	case "unescape":
+		result, err := unescapeString(jsonData)
+		if err != nil {
+			err = withInputPreview(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = writeOutput(out, result)
+	case "encoding":
+		raw := []byte(jsonData)
+		if opts.inputFile() {
+			raw, err = os.ReadFile(input)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error decoding base64: %v\n", err)
-				os.Exit(1)
+				fmt.Fprintf(stderr, "Error reading file: %v\n", err)
+				return 1, nil
 			}
-			inputToDecode = string(decodedBytes)
 		}
-		result, err := decodeJSON(inputToDecode)
+		outErr = writeOutput(out, detectEncoding(raw).String())
+	case "array":
+		data, err := parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		array, err := arrayAt(data, opts.pointer)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		if opts.expr != "" {
+			if array, err = filterExpr(array, opts.expr); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				return 1, nil
+			}
+		}
+		if opts.set["first"] {
+			array = selectFirst(array, opts.first)
+		}
+		if opts.set["last"] {
+			array = selectLast(array, opts.last)
+		}
+		if err := checkAssertions(array, opts.asserts); err != nil {
+			fmt.Fprintf(stderr, "Assertion failed: %v\n", err)
+			return exitAssertionFailed, nil
+		}
+		outErr = printJSON(out, array)
+	case "shuffle":
+		data, err := parseDocument(jsonData, opts.limits)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error decoding JSON: %v\n", err)
-			os.Exit(1)
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		array, err := arrayAt(data, opts.pointer)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = printJSON(out, shuffleArray(array))
+	case "tondjson":
+		data, err := parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		array, err := arrayAt(data, opts.pointer)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		if opts.expr != "" {
+			if array, err = filterExpr(array, opts.expr); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				return 1, nil
+			}
+		}
+		result, err := toNDJSON(array)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		if len(array) > 0 {
+			outErr = writeOutput(out, result)
+		}
+	case "fromndjson":
+		ndjson, err := ndjsonInput(args, jsonData, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading file: %v\n", err)
+			return 1, nil
+		}
+		values, warnings, err := fromNDJSON(ndjson, opts.skipInvalid)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(stderr, "Warning: %s\n", warning)
+		}
+		outErr = printJSON(out, values)
+	case "apply-patch":
+		target, patch, err := parseDocumentPair(jsonData, args, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = printJSON(out, mergePatch(target, patch))
+	case "apply-jsonpatch":
+		target, patch, err := parseDocumentPair(jsonData, args, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		result, err := applyJSONPatch(target, patch)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = printJSON(out, result)
+	case "gen-patch":
+		from, to, err := parseDocumentPair(jsonData, args, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		diffOpts, err := newDiffOptions(opts.ignorePaths, opts.floatTolerance)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		format, err := parseDiffFormat(opts.diffFormat)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = writeDiff(out, from, to, diffOpts, format)
+	case "project":
+		if opts.fields == "" {
+			fmt.Fprintf(stderr, "Error: --fields is required for project\n")
+			return 1, nil
+		}
+		data, err := parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		record, err := project(data, opts.fields, opts.omitMissing)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = printJSON(out, record)
+	case "pretty":
+		indent, err := parseIndent(opts.indent)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		if opts.set["inline-array-max"] && (!opts.pretty.inlineArrays || opts.pretty.inlineArrayMax < 1) {
+			fmt.Fprintf(stderr, "Error: --inline-array-max must be at least 1 and requires --inline-arrays\n")
+			return 1, nil
+		}
+		if opts.set["collapse-below"] && opts.pretty.collapseBelow < 1 {
+			fmt.Fprintf(stderr, "Error: --collapse-below must be at least 1\n")
+			return 1, nil
+		}
+		if opts.set["indent-levels"] && opts.pretty.indentLevels < 1 {
+			fmt.Fprintf(stderr, "Error: --indent-levels must be at least 1\n")
+			return 1, nil
+		}
+		opts.pretty.indent = indent
+		if _, err := parseDocument(jsonData, opts.limits); err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		result, err := prettyJSON(jsonData, opts.pretty)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = writeOutput(out, result)
+	case "dump":
+		data, err := parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		result, err := dumpLeaves(data, opts.kv, opts.separator)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = writeOutput(out, result)
+	case "exists":
+		data, err := parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		if _, err := parsePointer(opts.pointer); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		if !pathExists(data, opts.pointer, opts.nullAsAbsent) {
+			return exitAssertionFailed, nil
+		}
+	case "conform":
+		if opts.sample == "" {
+			fmt.Fprintf(stderr, "Error: --sample is required for conform\n")
+			return 1, nil
+		}
+		data, err := parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		sampleData, err := readFromFile(opts.sample)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading sample: %v\n", err)
+			return 1, nil
+		}
+		sample, err := parseDocument(sampleData, opts.limits)
+		if err != nil {
+			err = documentError(err, sampleData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error in sample: %v\n", err)
+			return 1, nil
+		}
+		divergences := conform(data, sample)
+		for _, d := range divergences {
+			if outErr = writeOutput(out, d.String()); outErr != nil {
+				break
+			}
+		}
+		if outErr == nil && len(divergences) > 0 {
+			return exitAssertionFailed, nil
+		}
+	case "group-by":
+		if opts.groupKey == "" {
+			fmt.Fprintf(stderr, "Error: --key is required for group-by\n")
+			return 1, nil
+		}
+		data, err := parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		array, err := arrayAt(data, opts.pointer)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		groups, err := groupBy(array, opts.groupKey, opts.missingKey)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = printJSON(out, groups)
+	case "split-by":
+		if opts.groupKey == "" || opts.outDir == "" {
+			fmt.Fprintf(stderr, "Error: --key and --out-dir are required for split-by\n")
+			return 1, nil
+		}
+		data, err := parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		array, err := arrayAt(data, opts.pointer)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		paths, err := splitBy(array, opts.groupKey, opts.missingKey, opts.outDir)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		for _, path := range paths {
+			if outErr = writeOutput(out, path); outErr != nil {
+				break
+			}
+		}
+	case "stats":
+		if opts.set["key"] {
+			fmt.Fprintf(stderr, "Error: stats takes the pointer to each element's value as --path, not --key\n")
+			return 1, nil
+		}
+		data, err := parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		// The array is the document itself; --path picks the value within
+		// each element
+		array, err := arrayAt(data, "")
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		stats, err := arrayStats(array, opts.pointer)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = printJSON(out, stats)
+	case "fromtoml":
+		data, err := fromTOML(jsonData)
+		if err != nil {
+			err = withInputPreview(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = printJSON(out, data)
+	case "totoml":
+		result, err := toTOML(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = writeOutput(out, result)
+	case "fromxml":
+		data, err := fromXML(jsonData)
+		if err != nil {
+			err = withInputPreview(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		if opts.transforms.stripKeyPrefix != "" {
+			if err := validateKeyCollision(opts.transforms.keyCollision); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				return 1, nil
+			}
+			if data, err = stripKeyPrefix(data, opts.transforms.stripKeyPrefix, opts.transforms.keyCollision); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				return 1, nil
+			}
+		}
+		outErr = printJSON(out, data)
+	case "toxml":
+		result, err := toXML(jsonData, opts.limits)
+		if err != nil {
+			err = documentError(err, jsonData, opts.previewBytes)
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1, nil
+		}
+		outErr = writeOutput(out, result)
+	default:
+		fmt.Fprintf(stderr, "Unknown command: %s\n", command)
+		fs.Usage()
+		return 1, nil
+	}
+
+	return 0, outErr
+}
+
+// finishOutput reports a failure to write the output, or output cut short
+// by --max-output, and returns the exit code. With --fail-on-warnings any
+// warning printed during the run makes it fail.
+func finishOutput(outErr error, capped *capWriter, opts options, stderr *warningCounter) int {
+	if outErr != nil {
+		fmt.Fprintf(stderr, "Error writing output: %v\n", outErr)
+		return 1
+	}
+	if capped != nil && capped.truncated() {
+		fmt.Fprintf(stderr, "Warning: output truncated at %d bytes (%d more bytes not shown)\n", opts.maxOutput, capped.dropped)
+	}
+	if opts.failOnWarnings && stderr.count > 0 {
+		fmt.Fprintf(stderr, "Error: %d warning(s) printed with --fail-on-warnings\n", stderr.count)
+		return exitWarnings
+	}
+	return 0
+}
+
+// decodeInput decodes the input of the decode command, first undoing base64
+// when requested. Errors name the step that failed.
+func decodeInput(jsonData string, opts options) (string, error) {
+	inputToDecode := jsonData
+	if opts.base64 {
+		decodedBytes, err := base64.StdEncoding.DecodeString(jsonData)
+		if err != nil {
+			return "", fmt.Errorf("decoding base64: %v", err)
+		}
+		inputToDecode = string(decodedBytes)
+	}
+	decode := decodeJSON
+	if opts.preserveEscapes {
+		decode = decodeJSONPreservingEscapes
+	}
+	result, err := decode(inputToDecode)
+	if err != nil {
+		return "", fmt.Errorf("decoding JSON: %v", err)
+	}
+	if opts.strictUTF8 {
+		if err := checkDecodedUTF8(inputToDecode); err != nil {
+			return "", fmt.Errorf("decoding JSON: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// ndjsonInput returns the input of fromndjson. A -f file is read again as
+// is, so that line numbers in errors match it.
+func ndjsonInput(args []string, jsonData string, opts options) (string, error) {
+	if !opts.inputFile() {
+		return jsonData, nil
+	}
+	raw, err := os.ReadFile(args[1])
+	return string(raw), err
+}
+
+// parseDocumentPair parses the two documents of a command taking a second
+// input after the first, such as "apply-patch <target> <patch>"
+func parseDocumentPair(first string, args []string, opts options) (interface{}, interface{}, error) {
+	if len(args) < 3 || args[2] == "" {
+		return nil, nil, fmt.Errorf("%s requires two inputs", args[0])
+	}
+	second := args[2]
+	if opts.fileInput {
+		var err error
+		if second, err = readFromFile(second); err != nil {
+			return nil, nil, fmt.Errorf("reading file: %v", err)
+		}
+	}
+
+	firstDoc, err := parseDocument(first, opts.limits)
+	if err != nil {
+		return nil, nil, fmt.Errorf("first input: %v", documentError(err, first, opts.previewBytes))
+	}
+	secondDoc, err := parseDocument(second, opts.limits)
+	if err != nil {
+		return nil, nil, fmt.Errorf("second input: %v", documentError(err, second, opts.previewBytes))
+	}
+	return firstDoc, secondDoc, nil
+}
+
+// runEach streams the elements of the array at the --each pointer and
+// writes the command's result for each element as it is read. Only the
+// tondjson and project commands work element by element.
+func runEach(command, input string, opts options, out, stderr io.Writer) int {
+	var perElement func(element interface{}) (interface{}, error)
+	switch strings.ToLower(command) {
+	case "tondjson":
+		perElement = func(element interface{}) (interface{}, error) {
+			return element, nil
+		}
+	case "project":
+		if opts.fields == "" {
+			fmt.Fprintf(stderr, "Error: --fields is required for project\n")
+			return 1
+		}
+		perElement = func(element interface{}) (interface{}, error) {
+			return project(element, opts.fields, opts.omitMissing)
+		}
+	default:
+		fmt.Fprintf(stderr, "Error: --each works with the tondjson and project commands, not %s\n", command)
+		return 1
+	}
+
+	var filter exprFunc
+	if opts.expr != "" {
+		var err error
+		if filter, err = compileExpr(opts.expr); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	var r io.Reader = strings.NewReader(input)
+	var file *os.File
+	if opts.eachInput != nil {
+		r = opts.eachInput
+	} else if opts.inputFile() {
+		var err error
+		if file, err = os.Open(input); err != nil {
+			fmt.Fprintf(stderr, "Error reading file: %v\n", err)
+			return 1
+		}
+		r = file
+	}
+
+	// Results are buffered for throughput; --line-buffered writes each one
+	// as soon as it is ready, for consumers reading a live stream
+	buffered := bufio.NewWriter(out)
+	counted := &countingReader{Reader: r}
+	var seen, skipped int
+	elementFailed := false
+	err := streamArray(counted, opts.each, func(index int, element interface{}) error {
+		seen++
+		// Every element draws, so a seed picks the same elements whatever
+		// --expr does with them
+		if opts.sampleRate > 0 && rng.Float64() >= opts.sampleRate {
+			skipped++
+			return nil
+		}
+		if filter != nil {
+			mapped, keep, err := evalElement(element, filter)
+			if err != nil {
+				elementFailed = true
+				return err
+			}
+			if !keep {
+				skipped++
+				return nil
+			}
+			element = mapped
+		}
+		result, err := perElement(element)
+		if err != nil {
+			elementFailed = true
+			return err
+		}
+		if opts.sse {
+			err = writeSSE(buffered, index, result, opts.sseID)
+		} else {
+			err = printJSON(buffered, result)
+		}
+		if err == nil && opts.lineBuffered {
+			err = buffered.Flush()
+		}
+		return err
+	})
+	// The results before a failure are still written
+	if flushErr := buffered.Flush(); err == nil && flushErr != nil {
+		err = flushErr
+	}
+	if file != nil {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("reading file: %v", closeErr)
+		}
+	}
+	if batch := opts.batch; batch != nil {
+		batch.processed += seen - skipped
+		batch.skipped += skipped
+		batch.bytesIn += counted.n
+		if err != nil {
+			batch.failed++
+			if !elementFailed {
+				// The element or document that could not be read
+				batch.processed++
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// prepareInput turns the input of a document command into JSON text,
+// converting it from --input-format and applying --coerce-input and
+// --best-effort. The input of other commands is returned unchanged.
+func prepareInput(command, jsonData string, opts options, stderr io.Writer) (string, error) {
+	if !documentCommands[strings.ToLower(command)] {
+		return jsonData, nil
+	}
+	converted, err := convertInput(jsonData, opts.inputFormat)
+	if err != nil {
+		return "", withInputPreview(err, jsonData, opts.previewBytes)
+	}
+	if opts.coerceInput {
+		converted, _ = coerceInput(converted)
+	}
+	if opts.bestEffort {
+		converted = bestEffortInput(converted, stderr)
+	}
+	return converted, nil
+}
+
+// runParseOnly parses the prepared input the way the command would and
+// discards the result. Nothing is written to stdout; with --measure the
+// time since start, which includes preparing the input, is reported on
+// stderr along with the size of the raw input.
+func runParseOnly(fs *flag.FlagSet, args []string, jsonData string, opts options, stderr io.Writer, start time.Time, size int) int {
+	command := strings.ToLower(args[0])
+	var err error
+	switch {
+	case command == "decode":
+		_, err = decodeInput(jsonData, opts)
+		if err != nil {
+			err = withInputPreview(err, jsonData, opts.previewBytes)
+		}
+	case command == "encode":
+		_, err = parseEncodeInput(jsonData, opts.limits, opts.allowBareString)
+		if err != nil {
+			err = fmt.Errorf("parsing JSON: %v", documentError(err, jsonData, opts.previewBytes))
+		}
+	case command == "escape":
+		if _, err = escapeString(jsonData); err != nil {
+			err = fmt.Errorf("parsing text: %v", err)
+		}
+	case command == "unescape":
+		if _, err = unescapeString(jsonData); err != nil {
+			err = fmt.Errorf("parsing text: %v", withInputPreview(err, jsonData, opts.previewBytes))
+		}
+	case command == "encoding":
+		// The encoding report reads the raw bytes and cannot fail
+	case command == "fromndjson":
+		var ndjson string
+		if ndjson, err = ndjsonInput(args, jsonData, opts); err != nil {
+			err = fmt.Errorf("reading file: %v", err)
+			break
+		}
+		var warnings []string
+		if _, warnings, err = fromNDJSON(ndjson, opts.skipInvalid); err != nil {
+			err = fmt.Errorf("parsing NDJSON: %v", err)
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(stderr, "Warning: %s\n", warning)
+		}
+	case command == "fromtoml":
+		if _, err = fromTOML(jsonData); err != nil {
+			err = fmt.Errorf("parsing TOML: %v", withInputPreview(err, jsonData, opts.previewBytes))
+		}
+	case command == "fromxml":
+		if _, err = fromXML(jsonData); err != nil {
+			err = fmt.Errorf("parsing XML: %v", withInputPreview(err, jsonData, opts.previewBytes))
+		}
+	case pairCommands[command]:
+		if _, _, err = parseDocumentPair(jsonData, args, opts); err != nil {
+			err = fmt.Errorf("parsing JSON: %v", err)
+		}
+	case documentCommands[command]:
+		_, err = parseDocument(jsonData, opts.limits)
+		if err != nil {
+			err = fmt.Errorf("parsing JSON: %v", documentError(err, jsonData, opts.previewBytes))
 		}
-		fmt.Println(result)
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
-		flag.Usage()
-		os.Exit(1)
+		fmt.Fprintf(stderr, "Unknown command: %s\n", args[0])
+		fs.Usage()
+		return 1
+	}
+	elapsed := time.Since(start)
+
+	if err != nil {
+		fmt.Fprintf(stderr, "Error %v\n", err)
+		return 1
+	}
+	if opts.measure {
+		fmt.Fprintf(stderr, "parse time: %v (%d bytes)\n", elapsed, size)
+	}
+	return 0
+}
+
+// printJSON writes a value to the output as minified JSON
+func printJSON(out io.Writer, value interface{}) error {
+	output, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
 	}
+	return writeOutput(out, string(output))
+}
+
+// writeOutput writes a result line to the output
+func writeOutput(out io.Writer, result string) error {
+	_, err := fmt.Fprintln(out, result)
+	return err
 }
 
 // readFromFile reads the entire content of a file
@@ -121,9 +1370,10 @@ func readFromFile(filename string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	defer file.Close()
-
 	content, err := io.ReadAll(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return "", err
 	}
@@ -131,15 +1381,92 @@ func readFromFile(filename string) (string, error) {
 	return strings.TrimSpace(string(content)), nil
 }
 
+// rawInputCommands read stdin as is rather than trimmed, the way they read
+// a -f file, so that byte counts and line numbers match the input
+var rawInputCommands = map[string]bool{
+	"encoding":   true,
+	"fromndjson": true,
+}
+
+// readFromStdin reads all of stdin, trimmed like a file when trim is set
+func readFromStdin(stdin io.Reader, trim bool) (string, error) {
+	if stdin == nil {
+		return "", fmt.Errorf("no standard input")
+	}
+	content, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+	if !trim {
+		return string(content), nil
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// readableStdin reports whether input can be read from stdin without an
+// argument: it must exist and not be an interactive terminal, where
+// waiting for input would look like a hang
+func readableStdin(stdin io.Reader) bool {
+	if stdin == nil {
+		return false
+	}
+	file, ok := stdin.(*os.File)
+	if !ok {
+		return true
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
 // encodeJSON takes a JSON string and encodes it for safe embedding
 // This validates the JSON and then marshals it as a string
 func encodeJSON(jsonStr string) (string, error) {
-	// First, validate and minify the input JSON
+	jsonData, err := parseJSON(jsonStr)
+	if err != nil {
+		return "", err
+	}
+	return encodeValue(jsonData)
+}
+
+// parseEncodeInput parses the input for encoding. With allowBareString,
+// input that is not valid JSON is taken as the text of a JSON string.
+func parseEncodeInput(input string, limits limitOptions, allowBareString bool) (interface{}, error) {
+	data, err := parseDocument(input, limits)
+	if err != nil && allowBareString && !json.Valid([]byte(input)) {
+		return input, nil
+	}
+	return data, err
+}
+
+// verifyEncodedInput checks that an encoded result round-trips to the
+// input it was produced from, after the same transforms
+func verifyEncodedInput(input, encoded string, transforms transformOptions) error {
+	// Input that is not JSON was encoded as a bare string
+	var expected interface{} = input
+	if json.Valid([]byte(input)) {
+		var err error
+		if expected, err = parseJSONNumbers(input); err != nil {
+			return err
+		}
+	}
+	expected, err := applyTransforms(expected, transforms)
+	if err != nil {
+		return err
+	}
+	return verifyEncoding(encoded, expected)
+}
+
+// parseJSON validates a JSON string and returns its parsed value
+func parseJSON(jsonStr string) (interface{}, error) {
 	var jsonData interface{}
 	if err := json.Unmarshal([]byte(jsonStr), &jsonData); err != nil {
-		return "", fmt.Errorf("invalid JSON input: %v", err)
+		return nil, fmt.Errorf("invalid JSON input: %v", err)
 	}
+	return jsonData, nil
+}
 
+// encodeValue minifies a parsed JSON value and quotes it for safe embedding
+func encodeValue(jsonData interface{}) (string, error) {
 	// Marshal the input as minified JSON (no extra whitespace)
 	minified, err := json.Marshal(jsonData)
 	if err != nil {
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestBase64RoundTrip(t *testing.T) {
@@ -300,3 +306,462 @@ func TestRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+func TestParseEncodeInputBareString(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		allowBareString bool
+		expected        string
+		wantErr         bool
+	}{
+		{
+			name:            "bare text",
+			input:           `hello`,
+			allowBareString: true,
+			expected:        `"\"hello\""`,
+		},
+		{
+			name:            "text with quotes",
+			input:           `say "hi"`,
+			allowBareString: true,
+			expected:        `"\"say \\\"hi\\\"\""`,
+		},
+		{
+			name:            "valid JSON is still parsed",
+			input:           `{"key": "value"}`,
+			allowBareString: true,
+			expected:        `"{\"key\":\"value\"}"`,
+		},
+		{
+			name:            "quoted JSON string is not double quoted",
+			input:           `"hello"`,
+			allowBareString: true,
+			expected:        `"\"hello\""`,
+		},
+		{
+			name:    "bare text rejected by default",
+			input:   `hello`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseEncodeInput(tt.input, limitOptions{}, tt.allowBareString)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseEncodeInput() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			result, err := encodeValue(data)
+			if err != nil {
+				t.Fatalf("encodeValue() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("encodeValue() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseEncodeInputKeepsLimitErrors(t *testing.T) {
+	_, err := parseEncodeInput(`[1, 2, 3]`, limitOptions{maxArrayLength: 2}, true)
+	if err == nil {
+		t.Errorf("parseEncodeInput() expected limit error for valid JSON input")
+	}
+}
+
+func TestRunParseOnly(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		exitCode   int
+		wantStderr string
+	}{
+		{
+			name:     "valid input",
+			args:     []string{"--parse-only", "encode", `{"key": "value"}`},
+			exitCode: 0,
+		},
+		{
+			name:       "invalid input",
+			args:       []string{"--parse-only", "encode", `{"key": value}`},
+			exitCode:   1,
+			wantStderr: "Error parsing JSON: invalid JSON input",
+		},
+		{
+			name:       "limits still apply",
+			args:       []string{"--parse-only", "--max-array-length", "1", "encode", `[1, 2]`},
+			exitCode:   1,
+			wantStderr: "exceeding --max-array-length 1",
+		},
+		{
+			name:     "valid encoded input for decode",
+			args:     []string{"--parse-only", "decode", `"{\"key\": \"value\"}"`},
+			exitCode: 0,
+		},
+		{
+			name:       "invalid encoded input for decode",
+			args:       []string{"--parse-only", "decode", `"not json"`},
+			exitCode:   1,
+			wantStderr: "Error decoding JSON",
+		},
+		{
+			name:       "measured parse",
+			args:       []string{"encode", "--parse-only", "--measure", `[1, 2, 3]`},
+			exitCode:   0,
+			wantStderr: "parse time: ",
+		},
+		{
+			name:     "bare string allowed",
+			args:     []string{"--parse-only", "--allow-bare-string", "encode", `say "hi"`},
+			exitCode: 0,
+		},
+		{
+			name:     "coerced input",
+			args:     []string{"--parse-only", "--coerce-input", "array", "hello"},
+			exitCode: 0,
+		},
+		{
+			name:       "toml input measured by raw size",
+			args:       []string{"--parse-only", "--measure", "--input-format", "toml", "encode", "a = 1\n"},
+			exitCode:   0,
+			wantStderr: "(6 bytes)",
+		},
+		{
+			name:       "input format errors reported",
+			args:       []string{"--parse-only", "--input-format", "toml", "encode", "a = "},
+			exitCode:   1,
+			wantStderr: "Error: ",
+		},
+		{
+			name:     "toml for fromtoml",
+			args:     []string{"--parse-only", "fromtoml", "a = 1"},
+			exitCode: 0,
+		},
+		{
+			name:       "invalid toml for fromtoml",
+			args:       []string{"--parse-only", "fromtoml", "a = "},
+			exitCode:   1,
+			wantStderr: "Error parsing TOML: ",
+		},
+		{
+			name:     "xml for fromxml",
+			args:     []string{"--parse-only", "fromxml", "<a/>"},
+			exitCode: 0,
+		},
+		{
+			name:       "invalid xml for fromxml",
+			args:       []string{"--parse-only", "fromxml", "<a>"},
+			exitCode:   1,
+			wantStderr: "Error parsing XML: ",
+		},
+		{
+			name:     "text for escape",
+			args:     []string{"--parse-only", "escape", "hello"},
+			exitCode: 0,
+		},
+		{
+			name:     "ndjson for fromndjson",
+			args:     []string{"--parse-only", "fromndjson", "{\"a\": 1}\n{\"a\": 2}"},
+			exitCode: 0,
+		},
+		{
+			name:       "invalid ndjson for fromndjson",
+			args:       []string{"--parse-only", "fromndjson", "{\"a\": 1}\nbad"},
+			exitCode:   1,
+			wantStderr: "Error parsing NDJSON: line 2",
+		},
+		{
+			name:       "second input of a pair",
+			args:       []string{"--parse-only", "gen-patch", `{}`, `{"a": }`},
+			exitCode:   1,
+			wantStderr: "Error parsing JSON: second input",
+		},
+		{
+			name:       "unknown command",
+			args:       []string{"--parse-only", "nosuchcmd", `{}`},
+			exitCode:   1,
+			wantStderr: "Unknown command: nosuchcmd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("run() wrote %q to stdout, want nothing", stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+			if tt.wantStderr == "" && stderr.Len() != 0 {
+				t.Errorf("run() stderr = %q, want nothing", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:     "encode",
+			args:     []string{"encode", `{"key": "value"}`},
+			exitCode: 0,
+			stdout:   "\"{\\\"key\\\":\\\"value\\\"}\"\n",
+		},
+		{
+			name:     "decode with options after the command",
+			args:     []string{"decode", "--base64", "IntcImFcIjoxfSI="},
+			exitCode: 0,
+			stdout:   "{\"a\":1}\n",
+		},
+		{
+			name:     "invalid input",
+			args:     []string{"encode", `{"key": value}`},
+			exitCode: 1,
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"reverse", `{}`},
+			exitCode: 1,
+		},
+		{
+			name:     "missing input",
+			args:     []string{"encode"},
+			exitCode: 1,
+		},
+		{
+			name:     "unknown flag",
+			args:     []string{"--no-such-flag", "encode", `{}`},
+			exitCode: 2,
+		},
+		{
+			name:     "help",
+			args:     []string{"-h"},
+			exitCode: 0,
+		},
+		{
+			name:     "help after the command",
+			args:     []string{"encode", "-h"},
+			exitCode: 0,
+		},
+		{
+			name:     "negative number input",
+			args:     []string{"encode", "-5"},
+			exitCode: 0,
+			stdout:   "\"-5\"\n",
+		},
+		{
+			name:     "yaml input starting with a dash",
+			args:     []string{"--input-format", "yaml", "encode", "- a"},
+			exitCode: 0,
+			stdout:   "\"[\\\"a\\\"]\"\n",
+		},
+		{
+			name:     "options after the input",
+			args:     []string{"encode", "[1]", "--root-key", "items"},
+			exitCode: 0,
+			stdout:   "\"{\\\"items\\\":[1]}\"\n",
+		},
+		{
+			name:     "double dash ends the options",
+			args:     []string{"encode", "--", "-1"},
+			exitCode: 0,
+			stdout:   "\"-1\"\n",
+		},
+		{
+			name:     "option name after double dash is an input",
+			args:     []string{"--input-format", "yaml", "encode", "--", "--force"},
+			exitCode: 0,
+			stdout:   "\"\\\"--force\\\"\"\n",
+		},
+		{
+			name:     "unknown option after the command",
+			args:     []string{"encode", "--no-such-flag", `{}`},
+			exitCode: 2,
+		},
+		{
+			name:     "extra input",
+			args:     []string{"encode", `{}`, `[]`},
+			exitCode: 2,
+		},
+		{
+			name:     "second input for a pair command",
+			args:     []string{"apply-patch", `{"a": 1}`, `{"a": null}`},
+			exitCode: 0,
+			stdout:   "{}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:   "no input argument",
+			args:   []string{"encode"},
+			stdin:  "{\"b\": 1, \"a\": 2}\n",
+			stdout: "\"{\\\"a\\\":2,\\\"b\\\":1}\"\n",
+		},
+		{
+			name:   "dash argument",
+			args:   []string{"decode", "-"},
+			stdin:  `"{\"key\": \"value\"}"`,
+			stdout: "{\"key\": \"value\"}\n",
+		},
+		{
+			name:   "dash with -f",
+			args:   []string{"-f", "array", "--first", "1", "-"},
+			stdin:  `[1, 2, 3]`,
+			stdout: "[1]\n",
+		},
+		{
+			name:   "options after the command",
+			args:   []string{"encode", "--root-key", "items"},
+			stdin:  `[1]`,
+			stdout: "\"{\\\"items\\\":[1]}\"\n",
+		},
+		{
+			name:   "encoding sees the raw bytes",
+			args:   []string{"encoding"},
+			stdin:  "\xef\xbb\xbf {}\n",
+			stdout: "encoding: UTF-8\nbom: true\nbytes: 7\ninvalid sequences: 0\n",
+		},
+		{
+			name:   "each",
+			args:   []string{"--each", "/items", "tondjson", "-"},
+			stdin:  "{\"items\": [1, {\"a\": 2}]}\n",
+			stdout: "1\n{\"a\":2}\n",
+		},
+		{
+			name:     "invalid input",
+			args:     []string{"encode"},
+			stdin:    `{"key": value}`,
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr); code != tt.exitCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
+
+func TestRunStdinReadError(t *testing.T) {
+	// stdin fails partway through an array. Without --each it is read whole
+	// before any output, so nothing reaches stdout; --each has already
+	// written the elements it read.
+	tests := []struct {
+		name   string
+		args   []string
+		stdout string
+	}{
+		{name: "encode", args: []string{"encode"}},
+		{name: "tondjson", args: []string{"tondjson"}},
+		{name: "each", args: []string{"--each", "", "tondjson"}, stdout: "1\n2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin := io.MultiReader(strings.NewReader("[1, 2, "), iotest.ErrReader(errors.New("connection reset")))
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, stdin, &stdout, &stderr); code != 1 {
+				t.Errorf("run() = %d, want 1", code)
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+			if !strings.Contains(stderr.String(), "connection reset") {
+				t.Errorf("run() stderr = %q, want the read error", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunStdinWithFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "new.json")
+	if err := os.WriteFile(target, []byte(`{"a": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// -f still applies to the second input when the first is stdin
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-f", "gen-patch", "-", target}, strings.NewReader("{\"a\": 1}\n"), &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	expected := `[{"op":"replace","path":"/a","value":2}]` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+}
+
+func TestRunStdinNDJSONLineNumbers(t *testing.T) {
+	ndjson := "\n\n{\"a\": 1}\nbad\n"
+	filename := filepath.Join(t.TempDir(), "records.ndjson")
+	if err := os.WriteFile(filename, []byte(ndjson), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var fromFile, fromStdin bytes.Buffer
+	run([]string{"-f", "fromndjson", filename}, nil, io.Discard, &fromFile)
+	run([]string{"fromndjson"}, strings.NewReader(ndjson), io.Discard, &fromStdin)
+	if !strings.Contains(fromFile.String(), "line 4:") {
+		t.Fatalf("run() stderr = %q, want the error on line 4", fromFile.String())
+	}
+	if fromStdin.String() != fromFile.String() {
+		t.Errorf("run() stderr = %q from stdin, want %q as from the file", fromStdin.String(), fromFile.String())
+	}
+}
+
+func TestRunStdinTerminal(t *testing.T) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		t.Skipf("no terminal: %v", err)
+	}
+	defer tty.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"encode"}, tty, &stdout, &stderr); code != 1 {
+		t.Errorf("run() = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "Usage:") {
+		t.Errorf("run() stderr = %q, want the usage", stderr.String())
+	}
+}
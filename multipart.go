@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// multipartBody wraps a value, as minified JSON, in a multipart/form-data
+// part named field. The result starts with the Content-Type header that
+// carries the boundary, followed by a blank line and the body. The boundary
+// is drawn from rng so that --seed makes it reproducible.
+func multipartBody(field string, value interface{}) (string, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to minify JSON: %v", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.SetBoundary(fmt.Sprintf("%016x%016x", rng.Uint64(), rng.Uint64())); err != nil {
+		return "", err
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(field)))
+	header.Set("Content-Type", "application/json")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(content); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return "Content-Type: " + writer.FormDataContentType() + "\r\n\r\n" + body.String(), nil
+}
+
+// quoteEscaper escapes a form field name for a quoted header parameter
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+func TestMultipartBody(t *testing.T) {
+	result, err := multipartBody(`my "field"`, map[string]interface{}{"b": 1.0, "a": "x"})
+	if err != nil {
+		t.Fatalf("multipartBody() error = %v", err)
+	}
+
+	header, body, found := strings.Cut(result, "\r\n\r\n")
+	if !found || !strings.HasPrefix(header, "Content-Type: ") {
+		t.Fatalf("multipartBody() = %q, want a Content-Type header and a blank line first", result)
+	}
+	mediaType, params, err := mime.ParseMediaType(strings.TrimPrefix(header, "Content-Type: "))
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("ParseMediaType() = %q, %v, want multipart/form-data", mediaType, err)
+	}
+	boundary := params["boundary"]
+	if !strings.HasPrefix(body, "--"+boundary+"\r\n") || !strings.HasSuffix(body, "\r\n--"+boundary+"--\r\n") {
+		t.Errorf("multipartBody() body = %q, want it framed by boundary %q", body, boundary)
+	}
+
+	reader := multipart.NewReader(strings.NewReader(body), boundary)
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart() error = %v", err)
+	}
+	if part.FormName() != `my "field"` {
+		t.Errorf("FormName() = %q, want %q", part.FormName(), `my "field"`)
+	}
+	if contentType := part.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("part Content-Type = %q, want application/json", contentType)
+	}
+	content, err := io.ReadAll(part)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(content) != `{"a":"x","b":1}` {
+		t.Errorf("part content = %s, want minified JSON", content)
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("NextPart() error = %v, want io.EOF after the only part", err)
+	}
+}
+
+func TestRunMultipartSeeded(t *testing.T) {
+	args := []string{"--seed", "5", "--multipart", "data", "encode", `{"k": [1, 2]}`}
+
+	var first, second, stderr bytes.Buffer
+	if code := run(args, nil, &first, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if code := run(args, nil, &second, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if first.String() != second.String() {
+		t.Errorf("run() with the same seed wrote %q then %q, want identical bodies", first.String(), second.String())
+	}
+	if !strings.Contains(first.String(), "\r\n\r\n{\"k\":[1,2]}\r\n") {
+		t.Errorf("run() stdout = %q, want the minified JSON as the part content", first.String())
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// toNDJSON formats each array element as minified JSON on its own line,
+// in order. The result has no trailing newline and is empty for an empty
+// array.
+func toNDJSON(array []interface{}) (string, error) {
+	lines := make([]string, len(array))
+	for i, element := range array {
+		line, err := json.Marshal(element)
+		if err != nil {
+			return "", fmt.Errorf("element %d: %v", i, err)
+		}
+		lines[i] = string(line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// fromNDJSON parses newline-delimited JSON into an array of its values.
+// Blank lines are skipped. A line that is not a single JSON value is an
+// error naming its line number, unless skipInvalid is set; then it is left
+// out and described in the returned warnings instead.
+func fromNDJSON(input string, skipInvalid bool) ([]interface{}, []string, error) {
+	values := []interface{}{}
+	var warnings []string
+	for i, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(line), &value); err != nil {
+			if !skipInvalid {
+				return nil, nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			warnings = append(warnings, fmt.Sprintf("skipping invalid line %d: %v", i+1, err))
+			continue
+		}
+		values = append(values, value)
+	}
+	return values, warnings, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToNDJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []interface{}
+		expected string
+	}{
+		{
+			name:     "one element per line in order",
+			input:    []interface{}{map[string]interface{}{"b": 2.0, "a": 1.0}, "two", 3.0, nil, []interface{}{true}},
+			expected: "{\"a\":1,\"b\":2}\n\"two\"\n3\nnull\n[true]",
+		},
+		{
+			name:     "strings with newlines stay on one line",
+			input:    []interface{}{"a\nb"},
+			expected: `"a\nb"`,
+		},
+		{
+			name:     "empty array",
+			input:    []interface{}{},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := toNDJSON(tt.input)
+			if err != nil {
+				t.Fatalf("toNDJSON() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("toNDJSON() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunToNDJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:   "top-level array",
+			args:   []string{"tondjson", `[{"id": 1}, {"id": 2}]`},
+			stdout: "{\"id\":1}\n{\"id\":2}\n",
+		},
+		{
+			name:   "nested array",
+			args:   []string{"--path", "/items", "tondjson", `{"items": [1, 2]}`},
+			stdout: "1\n2\n",
+		},
+		{
+			name: "empty array",
+			args: []string{"tondjson", `[]`},
+		},
+		{
+			name:     "object input",
+			args:     []string{"tondjson", `{"a": 1}`},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
+
+func TestFromNDJSON(t *testing.T) {
+	input := "{\"id\": 1}\n\n  [2, 3]  \r\n{\"id\": oops}\n\"four\"\n"
+
+	values, warnings, err := fromNDJSON(input, false)
+	if err == nil || err.Error() != "line 4: invalid character 'o' looking for beginning of value" {
+		t.Errorf("fromNDJSON() error = %v, want line 4 error", err)
+	}
+	if values != nil || warnings != nil {
+		t.Errorf("fromNDJSON() = %v, %v, want no result on error", values, warnings)
+	}
+
+	values, warnings, err = fromNDJSON(input, true)
+	if err != nil {
+		t.Fatalf("fromNDJSON() error = %v", err)
+	}
+	expected := []interface{}{
+		map[string]interface{}{"id": 1.0},
+		[]interface{}{2.0, 3.0},
+		"four",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("fromNDJSON() = %v, want %v", values, expected)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "skipping invalid line 4:") {
+		t.Errorf("fromNDJSON() warnings = %v, want one for line 4", warnings)
+	}
+}
+
+func TestFromNDJSONValid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []interface{}
+	}{
+		{name: "one value per line", input: "1\n\"a\"\nnull\n", expected: []interface{}{1.0, "a", nil}},
+		{name: "no trailing newline", input: "true\nfalse", expected: []interface{}{true, false}},
+		{name: "blank input", input: "\n\n", expected: []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _, err := fromNDJSON(tt.input, false)
+			if err != nil {
+				t.Fatalf("fromNDJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(values, tt.expected) {
+				t.Errorf("fromNDJSON() = %v, want %v", values, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunFromNDJSONFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"events.ndjson": "\n{\"id\": 1}\nbad\n{\"id\": 2}\n"})
+	path := filepath.Join(dir, "events.ndjson")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-f", "fromndjson", path}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("run() = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "line 3:") {
+		t.Errorf("run() stderr = %q, want the line number within the file", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--skip-invalid", "-f", "fromndjson", path}, nil, &stdout, &stderr); code != 0 {
+		t.Errorf("run() = %d, want 0", code)
+	}
+	if stdout.String() != "[{\"id\":1},{\"id\":2}]\n" {
+		t.Errorf("run() stdout = %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Warning: skipping invalid line 3") {
+		t.Errorf("run() stderr = %q, want a warning", stderr.String())
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// unicodeForms maps --unicode-normalize names to normalization forms
+var unicodeForms = map[string]norm.Form{
+	"nfc":  norm.NFC,
+	"nfd":  norm.NFD,
+	"nfkc": norm.NFKC,
+	"nfkd": norm.NFKD,
+}
+
+// parseUnicodeForm looks up a normalization form by its case-insensitive name
+func parseUnicodeForm(name string) (norm.Form, error) {
+	form, ok := unicodeForms[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown unicode normalization form %q (want nfc, nfd, nfkc or nfkd)", name)
+	}
+	return form, nil
+}
+
+// normalizeUnicode applies a normalization form to every string value, and
+// to object keys as well when keys is set. Keys that become identical after
+// normalization are resolved according to strategy.
+func normalizeUnicode(value interface{}, form norm.Form, keys bool, strategy string) (interface{}, error) {
+	value = normalizeUnicodeValues(value, form)
+	if !keys {
+		return value, nil
+	}
+	if err := validateKeyCollision(strategy); err != nil {
+		return nil, err
+	}
+	return renameKeys(value, form.String, strategy, "after unicode normalization")
+}
+
+func normalizeUnicodeValues(value interface{}, form norm.Form) interface{} {
+	switch v := value.(type) {
+	case string:
+		return form.String(v)
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			normalized[key] = normalizeUnicodeValues(child, form)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, child := range v {
+			normalized[i] = normalizeUnicodeValues(child, form)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNormalizeUnicode(t *testing.T) {
+	composed := "caf\u00e9"
+	decomposed := "cafe\u0301"
+
+	tests := []struct {
+		name     string
+		form     string
+		keys     bool
+		strategy string
+		input    interface{}
+		expected interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "nfc composes values",
+			form:     "nfc",
+			input:    map[string]interface{}{"name": decomposed, "list": []interface{}{decomposed, 1.0}},
+			expected: map[string]interface{}{"name": composed, "list": []interface{}{composed, 1.0}},
+		},
+		{
+			name:     "nfd decomposes values",
+			form:     "NFD",
+			input:    []interface{}{composed},
+			expected: []interface{}{decomposed},
+		},
+		{
+			name:     "nfkc folds compatibility characters",
+			form:     "nfkc",
+			input:    "\ufb01le",
+			expected: "file",
+		},
+		{
+			name:     "keys untouched by default",
+			form:     "nfc",
+			input:    map[string]interface{}{decomposed: decomposed},
+			expected: map[string]interface{}{decomposed: composed},
+		},
+		{
+			name:     "keys normalized when requested",
+			form:     "nfc",
+			keys:     true,
+			strategy: "error",
+			input:    map[string]interface{}{decomposed: decomposed},
+			expected: map[string]interface{}{composed: composed},
+		},
+		{
+			name:     "keys colliding after normalization",
+			form:     "nfc",
+			keys:     true,
+			strategy: "error",
+			input:    map[string]interface{}{decomposed: 1.0, composed: 2.0},
+			wantErr:  true,
+		},
+		{
+			name:     "colliding keys keep first",
+			form:     "nfc",
+			keys:     true,
+			strategy: "first",
+			input:    map[string]interface{}{decomposed: 1.0, composed: 2.0},
+			expected: map[string]interface{}{composed: 1.0},
+		},
+		{
+			name:     "colliding keys keep last",
+			form:     "nfc",
+			keys:     true,
+			strategy: "last",
+			input:    map[string]interface{}{"a": map[string]interface{}{decomposed: 1.0, composed: 2.0}},
+			expected: map[string]interface{}{"a": map[string]interface{}{composed: 2.0}},
+		},
+		{
+			name:     "unknown collision strategy",
+			form:     "nfc",
+			keys:     true,
+			strategy: "merge",
+			input:    map[string]interface{}{"a": 1.0},
+			wantErr:  true,
+		},
+		{
+			name:    "unknown form",
+			form:    "nfx",
+			input:   "text",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := applyTransforms(tt.input, transformOptions{unicodeForm: tt.form, normalizeKeys: tt.keys, keyCollision: tt.strategy})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("applyTransforms() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !equalJSON(result, tt.expected) {
+				t.Errorf("applyTransforms() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
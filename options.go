@@ -0,0 +1,334 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// options holds the settings given on the command line
+type options struct {
+	fileInput       bool
+	recursive       bool
+	gitignore       bool
+	ext             string
+	base64          bool
+	transforms      transformOptions
+	limits          limitOptions
+	pointer         string
+	first           int
+	last            int
+	groupKey        string
+	missingKey      string
+	outDir          string
+	teeFile         string
+	gzip            bool
+	emitBOM         bool
+	emitBOMStdout   bool
+	checksum        string
+	prefix          string
+	suffix          string
+	allowBareString bool
+	verify          bool
+	reportEscapes   bool
+	reformat        string
+	reportDupKeys   bool
+	preserveEscapes bool
+	unescapeASCII   bool
+	strictUTF8      bool
+	multipart       string
+	explain         bool
+	failOnWarnings  bool
+	nullAsAbsent    bool
+	parseOnly       bool
+	measure         bool
+	repeat          int
+	previewBytes    int
+	asserts         assertOptions
+	configFile      string
+	version         bool
+	skipInvalid     bool
+	histogram       bool
+	findDuplicates  bool
+	scanSecrets     bool
+	warnUnsafeInts  bool
+	sortObjectsBy   string
+	collation       string
+	maxOutput       int
+	fields          string
+	omitMissing     bool
+	coerceInput     bool
+	bestEffort      bool
+	inputFormat     string
+	kv              bool
+	separator       string
+	indent          string
+	pretty          prettyOptions
+	each            string
+	expr            string
+	sample          string
+	ignorePaths     stringList
+	floatTolerance  float64
+	diffFormat      string
+	seed            int64
+	summary         bool
+	sampleRate      float64
+	sse             bool
+	sseID           bool
+	lineBuffered    bool
+
+	// set records the names of the flags given explicitly
+	set map[string]bool
+	// stdinInput records that the first input was read from stdin, so -f
+	// applies only to the inputs after it
+	stdinInput bool
+	// batch, with --summary, tallies the inputs of --recursive and --each
+	batch *batchSummary
+	// eachInput, when not nil, is the reader --each streams in place of
+	// the input argument, such as stdin
+	eachInput io.Reader
+}
+
+// inputFile reports whether the first input argument names a file to read
+func (o options) inputFile() bool {
+	return o.fileInput && !o.stdinInput
+}
+
+// stringList collects the values of a flag that may be given more than once
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// newFlagSet registers every command line flag, storing the values in opts
+func newFlagSet(opts *options, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("jsonencoder", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	fs.StringVar(&opts.configFile, "config", "", "Read default option values from a JSON or YAML file")
+	fs.BoolVar(&opts.version, "version", false, "Print the version and exit")
+	fs.Int64Var(&opts.seed, "seed", 0, "Seed the random number generator so randomized output is reproducible")
+	fs.BoolVar(&opts.failOnWarnings, "fail-on-warnings", false, "Exit with code 4 if any warning was printed")
+	fs.BoolVar(&opts.fileInput, "f", false, "Read input from file")
+	fs.BoolVar(&opts.fileInput, "file", false, "Read input from file")
+	fs.BoolVar(&opts.recursive, "recursive", false, "With -f, run the command on every matching file under the given directory")
+	fs.StringVar(&opts.ext, "ext", ".json", "File extension --recursive looks for")
+	fs.BoolVar(&opts.gitignore, "respect-gitignore", false, "Skip files matched by .gitignore files with --recursive")
+	fs.BoolVar(&opts.base64, "base64", false, "Base64 encode/decode output/input")
+	fs.StringVar(&opts.transforms.rootKey, "root-key", "", "Wrap a non-object root value as {\"<name>\": <value>} when encoding")
+	fs.BoolVar(&opts.transforms.forceRoot, "force", false, "Apply --root-key even when the root is already an object")
+	fs.Var(&opts.transforms.addFields, "add-field", "Set the value at a JSON Pointer, given as pointer=value, creating missing objects; may be repeated (encode)")
+	fs.IntVar(&opts.transforms.clampDepth, "clamp-depth", 0, "Replace objects and arrays nested deeper than N levels with a placeholder")
+	fs.StringVar(&opts.transforms.clampPlaceholder, "clamp-placeholder", `"…"`, "JSON value that replaces content removed by --clamp-depth")
+	fs.StringVar(&opts.transforms.nullTo, "null-to", "", "Replace every null value with the given JSON value when encoding")
+	fs.StringVar(&opts.transforms.emptyPolicy, "empty-policy", "", "Convert empty values to null (empty-to-null) or null to empty values (null-to-empty) when encoding")
+	fs.StringVar(&opts.transforms.emptyType, "empty-type", "", "Type of empty value null becomes with --empty-policy null-to-empty: string, array or object")
+	fs.BoolVar(&opts.transforms.trimTrailingNull, "trim-trailing-nulls", false, "Remove null elements from the end of arrays when encoding")
+	fs.BoolVar(&opts.transforms.trimAllNulls, "trim-all-nulls", false, "Remove every null element from arrays when encoding")
+	fs.StringVar(&opts.transforms.unicodeForm, "unicode-normalize", "", "Apply Unicode normalization (nfc, nfd, nfkc or nfkd) to string values")
+	fs.BoolVar(&opts.transforms.normalizeKeys, "normalize-keys", false, "Also apply --unicode-normalize to object keys")
+	fs.StringVar(&opts.transforms.timestampLayout, "normalize-timestamps", "", "Rewrite string values that parse as timestamps in the given layout, in UTC")
+	fs.StringVar(&opts.transforms.timestampKeys, "timestamp-keys", "", "Comma-separated key names to which --normalize-timestamps is limited")
+	fs.BoolVar(&opts.transforms.coerceBooleans, "coerce-booleans", false, "Turn strings like \"yes\", \"off\" and \"1\" into booleans when encoding")
+	fs.StringVar(&opts.transforms.booleanKeys, "boolean-keys", "", "Comma-separated key names to which --coerce-booleans is limited")
+	fs.BoolVar(&opts.transforms.resolveIncludes, "resolve-includes", false, "Replace {\"$include\": \"file\"} objects with the contents of the file when encoding")
+	fs.BoolVar(&opts.transforms.resolveRefs, "resolve-refs", false, "Replace {\"$ref\": \"#/pointer\"} objects with the value they point to when encoding")
+	fs.BoolVar(&opts.transforms.trimKeys, "trim-keys", false, "Trim leading and trailing whitespace from object keys")
+	fs.StringVar(&opts.transforms.stripKeyPrefix, "strip-key-prefix", "", "Remove everything up to and including the first separator in object keys (encode, fromxml)")
+	fs.BoolVar(&opts.transforms.envsubstKeys, "envsubst-keys", false, "Expand ${VAR} references in object keys from the environment")
+	fs.BoolVar(&opts.transforms.overwrite, "overwrite", false, "Let keys expanded by --envsubst-keys replace existing keys instead of failing")
+	fs.BoolVar(&opts.transforms.stripControl, "strip-control", false, "Remove ASCII control characters from string values")
+	fs.StringVar(&opts.transforms.replaceControl, "replace-control", "", "Replace ASCII control characters in string values with the given text")
+	fs.BoolVar(&opts.transforms.controlKeys, "include-keys", false, "Also apply --strip-control or --replace-control to object keys")
+	fs.StringVar(&opts.transforms.keyCollision, "key-collision", "error", "How to resolve keys that collide after rewriting: error, first or last")
+	fs.StringVar(&opts.sortObjectsBy, "sort-objects-by", "key", "Order object members by key name or by serialized value when encoding")
+	fs.StringVar(&opts.collation, "collation", "", "Compare key names by the collation rules of a locale instead of by code point when encoding")
+	fs.BoolVar(&opts.transforms.jsNumbers, "js-numbers", false, "Write numbers exactly as JavaScript's JSON.stringify would")
+	fs.StringVar(&opts.transforms.pipeline, "pipeline", "", "Comma-separated transform stages to apply in order when encoding")
+	fs.IntVar(&opts.limits.maxArrayLength, "max-array-length", 0, "Reject documents containing an array with more than N elements")
+	fs.IntVar(&opts.limits.maxNodes, "max-nodes", 0, "Reject documents containing more than N values in total")
+	fs.BoolVar(&opts.limits.strictNumbers, "strict-numbers", false, "Reject documents containing numbers that would lose precision as float64")
+	fs.StringVar(&opts.pointer, "path", "", "JSON Pointer addressing the value a command operates on; for stats, relative to each array element")
+	fs.BoolVar(&opts.nullAsAbsent, "treat-null-as-absent", false, "Count a null value as missing (exists)")
+	fs.IntVar(&opts.first, "first", 0, "Keep only the first N array elements")
+	fs.IntVar(&opts.last, "last", 0, "Keep only the last N array elements")
+	fs.StringVar(&opts.groupKey, "key", "", "JSON Pointer, relative to each element, of the value to group by")
+	fs.StringVar(&opts.missingKey, "missing-key", "_missing", "Group name for elements without the --key value")
+	fs.StringVar(&opts.outDir, "out-dir", "", "Directory to write one file per group to (split-by)")
+	fs.StringVar(&opts.sample, "sample", "", "Sample document whose key sets and types to compare against (conform)")
+	fs.Var(&opts.ignorePaths, "ignore-path", "JSON Pointer of a value whose differences are ignored; may be repeated (gen-patch)")
+	fs.Float64Var(&opts.floatTolerance, "float-tolerance", 0, "Treat numbers that are not both integers as equal within this distance (gen-patch)")
+	fs.StringVar(&opts.diffFormat, "diff-format", "patch", "Output of gen-patch: patch (RFC 6902), json or text")
+	fs.BoolVar(&opts.skipInvalid, "skip-invalid", false, "Skip lines that are not valid JSON instead of failing (fromndjson)")
+	fs.StringVar(&opts.fields, "fields", "", "Comma-separated JSON Pointers of the fields to extract (project)")
+	fs.BoolVar(&opts.omitMissing, "omit-missing", false, "Leave out fields missing from the document instead of using null (project)")
+	fs.BoolVar(&opts.kv, "kv", false, "Print leaves as \"path = value\" lines (dump)")
+	fs.StringVar(&opts.separator, "separator", "=", "Separator between path and value with --kv (dump)")
+	fs.StringVar(&opts.indent, "indent", "2", "Number of spaces, or spaces and tabs such as \"\\t\", to indent each level by (pretty)")
+	fs.BoolVar(&opts.pretty.inlineArrays, "inline-arrays", false, "Keep arrays holding only scalars on one line (pretty)")
+	fs.IntVar(&opts.pretty.inlineArrayMax, "inline-array-max", 0, "Wrap arrays with more than N elements despite --inline-arrays (pretty)")
+	fs.IntVar(&opts.pretty.collapseBelow, "collapse-below", 0, "Summarize objects and arrays more than N levels deep as {…3 keys} or […10 items] (pretty)")
+	fs.IntVar(&opts.pretty.indentLevels, "indent-levels", 0, "Write objects and arrays N levels below the root on one line (pretty)")
+	fs.StringVar(&opts.each, "each", "", "Stream the array at this JSON Pointer one element at a time (tondjson, project)")
+	fs.Float64Var(&opts.sampleRate, "sample-rate", 0, "Pass each --each element through with this probability, reproducibly with --seed")
+	fs.BoolVar(&opts.sse, "sse", false, "Write each --each result as a Server-Sent Events message, data: <json> and a blank line")
+	fs.BoolVar(&opts.sseID, "sse-id", false, "Start each --sse message with an id: line holding the element's index")
+	fs.BoolVar(&opts.lineBuffered, "line-buffered", false, "Write each --each result as soon as it is ready instead of in blocks")
+	fs.BoolVar(&opts.summary, "summary", false, "Write counts of the inputs processed, failed and skipped by --recursive or --each to stderr")
+	fs.StringVar(&opts.expr, "expr", "", "Expression evaluated against each array element to filter or map it (array, tondjson, --each)")
+	fs.StringVar(&opts.teeFile, "tee", "", "Also write the output to the given file")
+	fs.BoolVar(&opts.gzip, "gzip", false, "Compress the --tee file with gzip, adding \".gz\" to its name")
+	fs.BoolVar(&opts.emitBOM, "emit-bom", false, "Start the --tee file with a UTF-8 byte order mark")
+	fs.BoolVar(&opts.emitBOMStdout, "emit-bom-stdout", false, "Start stdout with a UTF-8 byte order mark")
+	fs.StringVar(&opts.checksum, "checksum", "", "Write a sha256 or sha512 digest of the --tee file to <file>.<algorithm>")
+	fs.IntVar(&opts.maxOutput, "max-output", 0, "Write at most N bytes to stdout, reporting on stderr when output was cut")
+	fs.StringVar(&opts.prefix, "prefix", "", "Text to prepend to every output line")
+	fs.StringVar(&opts.suffix, "suffix", "", "Text to append to every output line")
+	fs.BoolVar(&opts.allowBareString, "allow-bare-string", false, "Encode input that is not valid JSON as a plain string")
+	fs.StringVar(&opts.inputFormat, "input-format", "json", "Format of the input document: json, yaml, toml or auto")
+	fs.BoolVar(&opts.coerceInput, "coerce-input", false, "Read input that is not JSON, objects and arrays aside, as a JSON string")
+	fs.BoolVar(&opts.bestEffort, "best-effort", false, "Recover the complete values before a syntax error in invalid input, with a warning")
+	fs.StringVar(&opts.multipart, "multipart", "", "Write the minified JSON as a multipart/form-data part with this field name (encode)")
+	fs.BoolVar(&opts.verify, "verify", false, "Decode the encoded output again and check it matches the input")
+	fs.BoolVar(&opts.reportEscapes, "report-escapes", false, "List the characters escaped by encode on stderr")
+	fs.StringVar(&opts.reformat, "reformat", "", "Re-serialize decoded JSON as compact, pretty or sorted")
+	fs.BoolVar(&opts.explain, "explain", false, "Report the input and decoded lengths, escape count and inner validity on stderr (decode)")
+	fs.BoolVar(&opts.reportDupKeys, "report-dup-keys", false, "List objects containing duplicate keys on stderr")
+	fs.BoolVar(&opts.histogram, "histogram", false, "Write the count of values of each JSON type to stderr as a JSON object")
+	fs.BoolVar(&opts.findDuplicates, "find-duplicates", false, "List repeated identical objects and arrays, with their size, on stderr")
+	fs.BoolVar(&opts.scanSecrets, "scan-secrets", false, "Warn on stderr about string values that look like passwords, keys or tokens")
+	fs.BoolVar(&opts.warnUnsafeInts, "warn-unsafe-integers", false, "Warn on stderr about integers JavaScript cannot represent exactly")
+	fs.BoolVar(&opts.preserveEscapes, "preserve-escapes", false, "Keep optional escapes like \\/ and \\u0041 in decoded strings")
+	fs.BoolVar(&opts.unescapeASCII, "unescape-ascii", false, "Write \\uXXXX escapes of printable ASCII characters in decoded strings as the characters")
+	fs.BoolVar(&opts.strictUTF8, "strict-utf8-decode", false, "Fail when the decoded string would not be valid UTF-8 instead of using replacement characters")
+	fs.BoolVar(&opts.parseOnly, "parse-only", false, "Parse the input and discard it without writing any output")
+	fs.BoolVar(&opts.measure, "measure", false, "Report the parse time on stderr (with --parse-only)")
+	fs.IntVar(&opts.repeat, "repeat", 1, "Run the command N times, writing the result once and the timing to stderr")
+	fs.IntVar(&opts.previewBytes, "preview-bytes", 0, "Show the first and last N bytes of input that fails to parse")
+	fs.BoolVar(&opts.asserts.nonEmpty, "assert-nonempty", false, "Exit with code 3 if the value is an empty object, array or string")
+	fs.StringVar(&opts.asserts.typeName, "assert-type", "", "Exit with code 3 unless the value has the given JSON type")
+
+	fs.Usage = func() {
+		progName := os.Args[0]
+		fmt.Fprintf(stderr, usage, progName, progName, progName, progName, progName, progName)
+	}
+	return fs
+}
+
+// parseArgs parses the command line into opts and returns the positional
+// arguments, starting with the command
+func parseArgs(args []string, opts *options, stderr io.Writer) (*flag.FlagSet, []string, error) {
+	fs := newFlagSet(opts, stderr)
+	if err := fs.Parse(args); err != nil {
+		return fs, nil, err
+	}
+
+	args = fs.Args()
+	if len(args) > 0 {
+		// Options may also follow the command, e.g. "encode -f input.json"
+		command := args[0]
+		inputs, err := parseCommandArgs(fs, args[1:])
+		if err != nil {
+			return fs, nil, err
+		}
+		if max := maxInputs(command); len(inputs) > max {
+			fmt.Fprintf(stderr, "Error: %s takes at most %d input(s), got %d: %q\n", command, max, len(inputs), inputs)
+			return fs, nil, fmt.Errorf("too many inputs")
+		}
+		args = append([]string{command}, inputs...)
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	if err := applyDefaults(fs, opts.configFile, explicit); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return fs, nil, err
+	}
+
+	// Values from the config file and environment count as set
+	opts.set = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		opts.set[f.Name] = true
+	})
+	return fs, args, nil
+}
+
+// pairCommands lists the commands taking a second input after the first
+var pairCommands = map[string]bool{
+	"apply-patch":     true,
+	"apply-jsonpatch": true,
+	"gen-patch":       true,
+}
+
+// maxInputs returns how many inputs may follow the command
+func maxInputs(command string) int {
+	if pairCommands[command] {
+		return 2
+	}
+	return 1
+}
+
+// parseCommandArgs parses the options among the arguments following the
+// command and returns the inputs. Options may come before or after the
+// inputs and "--" ends them. An argument starting with a single "-" is an
+// option only when one of that name exists, so inputs such as "-5", "-"
+// or the YAML "- a" are kept as they are.
+func parseCommandArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var flags, inputs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			inputs = append(inputs, args[i+1:]...)
+			break
+		}
+		name, ok := optionName(fs, arg)
+		if !ok {
+			inputs = append(inputs, arg)
+			continue
+		}
+		flags = append(flags, arg)
+		if !strings.Contains(arg, "=") && !isBoolFlag(fs, name) && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	if err := fs.Parse(flags); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+// optionName returns the name of the option arg refers to and whether arg
+// is an option at all. Any "--name" is an option, so that a misspelt one
+// is reported rather than taken as an input.
+func optionName(fs *flag.FlagSet, arg string) (string, bool) {
+	if strings.HasPrefix(arg, "--") && len(arg) > 2 {
+		name, _, _ := strings.Cut(arg[2:], "=")
+		return name, true
+	}
+	if !strings.HasPrefix(arg, "-") || len(arg) < 2 {
+		return "", false
+	}
+	name, _, _ := strings.Cut(arg[1:], "=")
+	return name, fs.Lookup(name) != nil || name == "h" || name == "help"
+}
+
+// isBoolFlag reports whether the named option takes no value
+func isBoolFlag(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
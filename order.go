@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// orderedObject is an object that serializes its members in a given order
+// instead of the key order used by encoding/json for maps
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// MarshalJSON writes the members in the order of keys
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// sortObjectsBy prepares a document for serialization with its object
+// members ordered by "key" name, the encoding/json default, or by the
+// minified serialization of their "value", ties broken by key name. With
+// a collation locale, key names are compared by that locale's rules
+// instead of by code point.
+func sortObjectsBy(value interface{}, order, collation string) (interface{}, error) {
+	keys := sortedKeys
+	if collation != "" {
+		tag, err := language.Parse(collation)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --collation %q: %v", collation, err)
+		}
+		keys = collatedKeys(collate.New(tag))
+	}
+	switch order {
+	case "", "key":
+		if collation == "" {
+			return value, nil
+		}
+		return orderObjects(value, keys, false)
+	case "value":
+		return orderObjects(value, keys, true)
+	default:
+		return nil, fmt.Errorf("unknown --sort-objects-by %q (want key or value)", order)
+	}
+}
+
+// collatedKeys returns a function listing the keys of an object in the
+// order of a collator. Keys the collator finds equal stay in code point
+// order.
+func collatedKeys(collator *collate.Collator) func(map[string]interface{}) []string {
+	return func(object map[string]interface{}) []string {
+		keys := sortedKeys(object)
+		sort.SliceStable(keys, func(i, j int) bool {
+			return collator.CompareString(keys[i], keys[j]) < 0
+		})
+		return keys
+	}
+}
+
+// orderObjects replaces every object with an orderedObject whose members
+// are listed by keys, then with byValue stably sorted by their serialized
+// values
+func orderObjects(value interface{}, keys func(map[string]interface{}) []string, byValue bool) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(v))
+		serialized := make(map[string]string, len(v))
+		for _, key := range sortedKeys(v) {
+			child, err := orderObjects(v[key], keys, byValue)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = child
+			if byValue {
+				text, err := json.Marshal(child)
+				if err != nil {
+					return nil, err
+				}
+				serialized[key] = string(text)
+			}
+		}
+		ordered := keys(v)
+		if byValue {
+			sort.SliceStable(ordered, func(i, j int) bool {
+				return serialized[ordered[i]] < serialized[ordered[j]]
+			})
+		}
+		return orderedObject{keys: ordered, values: values}, nil
+	case []interface{}:
+		ordered := make([]interface{}, len(v))
+		for i, child := range v {
+			child, err := orderObjects(child, keys, byValue)
+			if err != nil {
+				return nil, err
+			}
+			ordered[i] = child
+		}
+		return ordered, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSortObjectsBy(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     string
+		collation string
+		input     string
+		expected  string
+		wantErr   bool
+	}{
+		{
+			name:     "key order by default",
+			order:    "key",
+			input:    `{"b": 2, "a": 3, "c": 1}`,
+			expected: `{"a":3,"b":2,"c":1}`,
+		},
+		{
+			name:     "value order",
+			order:    "value",
+			input:    `{"b": 2, "a": 3, "c": 1}`,
+			expected: `{"c":1,"b":2,"a":3}`,
+		},
+		{
+			name:     "ties broken by key name",
+			order:    "value",
+			input:    `{"z": 1, "m": 1, "a": 2}`,
+			expected: `{"m":1,"z":1,"a":2}`,
+		},
+		{
+			name:     "values compared as serialized text",
+			order:    "value",
+			input:    `{"n": 10, "m": 9, "s": "x", "o": {}, "l": [], "t": true, "u": null}`,
+			expected: `{"s":"x","n":10,"m":9,"l":[],"u":null,"t":true,"o":{}}`,
+		},
+		{
+			name:     "nested objects ordered first",
+			order:    "value",
+			input:    `{"x": {"q": 2, "p": 1}, "y": {"p": 1, "q": 1}}`,
+			expected: `{"y":{"p":1,"q":1},"x":{"p":1,"q":2}}`,
+		},
+		{
+			name:     "objects inside arrays",
+			order:    "value",
+			input:    `[{"b": "a", "a": "b"}]`,
+			expected: `[{"b":"a","a":"b"}]`,
+		},
+		{
+			name:     "code point key order",
+			order:    "key",
+			input:    `{"zebra": 1, "äpple": 2, "apple": 3, "Bob": 4}`,
+			expected: `{"Bob":4,"apple":3,"zebra":1,"äpple":2}`,
+		},
+		{
+			name:      "english collation",
+			order:     "key",
+			collation: "en",
+			input:     `{"zebra": 1, "äpple": 2, "apple": 3, "Bob": 4}`,
+			expected:  `{"apple":3,"äpple":2,"Bob":4,"zebra":1}`,
+		},
+		{
+			name:      "swedish collation",
+			order:     "key",
+			collation: "sv",
+			input:     `{"zebra": 1, "äpple": 2, "apple": 3, "Bob": 4}`,
+			expected:  `{"apple":3,"Bob":4,"zebra":1,"äpple":2}`,
+		},
+		{
+			name:      "value ties broken by collated key",
+			order:     "value",
+			collation: "en",
+			input:     `{"b": 1, "B": 1, "a": 2}`,
+			expected:  `{"b":1,"B":1,"a":2}`,
+		},
+		{
+			name:      "invalid locale",
+			order:     "key",
+			collation: "not a locale",
+			input:     `{}`,
+			wantErr:   true,
+		},
+		{
+			name:    "unknown order",
+			order:   "length",
+			input:   `{}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			ordered, err := sortObjectsBy(data, tt.order, tt.collation)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sortObjectsBy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			result, err := json.Marshal(ordered)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("sortObjectsBy() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
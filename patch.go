@@ -0,0 +1,499 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// mergePatch applies an RFC 7386 JSON Merge Patch to a target document.
+// Objects in the patch are merged into the target recursively, a null
+// member removes the key from the target, and any other patch value
+// replaces the target as a whole. The target is not modified.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result := make(map[string]interface{})
+	if targetObject, ok := target.(map[string]interface{}); ok {
+		for key, value := range targetObject {
+			result[key] = value
+		}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = mergePatch(result[key], value)
+	}
+	return result
+}
+
+// applyJSONPatch applies an RFC 6902 JSON Patch, an array of add, remove,
+// replace, move, copy and test operations, to a document. Operations are
+// applied in order and the first one that fails, including a failed test,
+// aborts the whole patch.
+func applyJSONPatch(doc interface{}, patch interface{}) (interface{}, error) {
+	operations, ok := patch.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSON Patch must be an array of operations, got %s", jsonType(patch))
+	}
+
+	for i, item := range operations {
+		operation, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation %d: must be an object, got %s", i, jsonType(item))
+		}
+		op, _ := operation["op"].(string)
+		var err error
+		doc, err = applyPatchOperation(doc, op, operation)
+		if err != nil {
+			if op == "" {
+				return nil, fmt.Errorf("operation %d: %v", i, err)
+			}
+			return nil, fmt.Errorf("operation %d (%s): %v", i, op, err)
+		}
+	}
+	return doc, nil
+}
+
+// applyPatchOperation applies a single JSON Patch operation
+func applyPatchOperation(doc interface{}, op string, operation map[string]interface{}) (interface{}, error) {
+	path, err := patchPointer(operation, "path")
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "add":
+		value, err := patchValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, path, value)
+	case "replace":
+		value, err := patchValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		return patchReplace(doc, path, value)
+	case "test":
+		value, err := patchValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		current, err := resolvePointer(doc, formatPointer(path))
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("test failed: value at %q is %s, want %s", formatPointer(path), compactJSON(current), compactJSON(value))
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err := patchRemove(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := patchPointer(operation, "from")
+		if err != nil {
+			return nil, err
+		}
+		if op == "copy" {
+			value, err := resolvePointer(doc, formatPointer(from))
+			if err != nil {
+				return nil, err
+			}
+			return patchAdd(doc, path, deepCopy(value))
+		}
+		if len(path) > len(from) && formatPointer(path[:len(from)]) == formatPointer(from) {
+			return nil, fmt.Errorf("cannot move %q into its own child %q", formatPointer(from), formatPointer(path))
+		}
+		doc, value, err := patchRemove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, path, value)
+	case "":
+		return nil, fmt.Errorf(`missing "op"`)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op)
+	}
+}
+
+// patchPointer reads and parses a JSON Pointer member of an operation
+func patchPointer(operation map[string]interface{}, member string) ([]string, error) {
+	pointer, ok := operation[member].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing or non-string %q", member)
+	}
+	return parsePointer(pointer)
+}
+
+// patchValue reads the value member of an operation, which may be null
+func patchValue(operation map[string]interface{}) (interface{}, error) {
+	value, ok := operation["value"]
+	if !ok {
+		return nil, fmt.Errorf(`missing "value"`)
+	}
+	return value, nil
+}
+
+// patchAdd adds a value at path, inserting into arrays and creating or
+// replacing object members. The index "-" appends to an array.
+func patchAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return patchParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[token] = value
+			return p, nil
+		case []interface{}:
+			index := len(p)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(p)+1); err != nil {
+					return nil, err
+				}
+			}
+			p = append(p, nil)
+			copy(p[index+1:], p[index:])
+			p[index] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("cannot add to %s at %q", jsonType(parent), formatPointer(path[:len(path)-1]))
+		}
+	})
+}
+
+// patchRemove removes the value at path and returns it
+func patchRemove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+	var removed interface{}
+	doc, err := patchParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			value, ok := p[token]
+			if !ok {
+				return nil, fmt.Errorf("no value at %q", formatPointer(path))
+			}
+			removed = value
+			delete(p, token)
+			return p, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(p))
+			if err != nil {
+				return nil, err
+			}
+			removed = p[index]
+			return append(p[:index:index], p[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("no value at %q", formatPointer(path))
+		}
+	})
+	return doc, removed, err
+}
+
+// patchReplace replaces the existing value at path
+func patchReplace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return patchParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[token]; !ok {
+				return nil, fmt.Errorf("no value at %q", formatPointer(path))
+			}
+			p[token] = value
+			return p, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(p))
+			if err != nil {
+				return nil, err
+			}
+			p[index] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("no value at %q", formatPointer(path))
+		}
+	})
+}
+
+// patchParent finds the container holding the last token of a non-empty
+// path and replaces it with the result of fn, which may return a new
+// container, as inserting into or removing from an array does
+func patchParent(node interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", path[0])
+		}
+		updated, err := patchParent(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = updated
+		return n, nil
+	case []interface{}:
+		index, err := arrayIndex(path[0], len(n))
+		if err != nil {
+			return nil, err
+		}
+		updated, err := patchParent(n[index], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[index] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("cannot index into %s with %q", jsonType(node), path[0])
+	}
+}
+
+// deepCopy returns a copy of a parsed document sharing no objects or arrays
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = deepCopy(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// compactJSON formats a value for an error message
+func compactJSON(value interface{}) string {
+	output, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(output)
+}
+
+// diffOptions adjusts how generateJSONPatch compares documents
+type diffOptions struct {
+	// ignorePaths holds JSON Pointers, in the form formatPointer writes,
+	// of values whose differences are left out of the patch
+	ignorePaths map[string]bool
+	// tolerance is how far apart two numbers that are not both integers
+	// may be and still compare equal
+	tolerance float64
+}
+
+// newDiffOptions builds diffOptions from the --ignore-path pointers and
+// --float-tolerance
+func newDiffOptions(ignorePaths []string, tolerance float64) (diffOptions, error) {
+	if tolerance < 0 || math.IsNaN(tolerance) {
+		return diffOptions{}, fmt.Errorf("invalid --float-tolerance %v: must not be negative", tolerance)
+	}
+	opts := diffOptions{ignorePaths: make(map[string]bool), tolerance: tolerance}
+	for _, pointer := range ignorePaths {
+		tokens, err := parsePointer(pointer)
+		if err != nil {
+			return diffOptions{}, fmt.Errorf("invalid --ignore-path: %v", err)
+		}
+		opts.ignorePaths[formatPointer(tokens)] = true
+	}
+	return opts, nil
+}
+
+// ignored reports whether differences at path are left out of the patch.
+// Everything below an ignored path is ignored too, as diffValues never
+// descends into it.
+func (o diffOptions) ignored(path []string) bool {
+	return o.ignorePaths[formatPointer(path)]
+}
+
+// withinTolerance reports whether from and to are numbers close enough to
+// compare equal. Integers are always compared exactly.
+func (o diffOptions) withinTolerance(from, to interface{}) bool {
+	f, ok := from.(float64)
+	if !ok || o.tolerance == 0 {
+		return false
+	}
+	t, ok := to.(float64)
+	if !ok || (f == math.Trunc(f) && t == math.Trunc(t)) {
+		return false
+	}
+	return math.Abs(f-t) <= o.tolerance
+}
+
+// generateJSONPatch computes an RFC 6902 JSON Patch that turns from into
+// to. Changed values are replaced in place rather than removed and added
+// again; arrays are compared index by index, with elements appended or
+// removed from the end when the lengths differ. Object keys are visited in
+// sorted order, so the patch is deterministic.
+func generateJSONPatch(from, to interface{}, opts diffOptions) []interface{} {
+	patch := []interface{}{}
+	for _, c := range diffValues(from, to, nil, nil, opts) {
+		path := c.path
+		if c.appended {
+			path = appendToken(path[:len(path)-1], "-")
+		}
+		patch = append(patch, patchOperation(c.op, path, c.after, c.op != "remove"))
+	}
+	return patch
+}
+
+// change is one difference between two documents, in the order and with
+// the operation name of the JSON Patch that applies it. The path of an
+// added array element holds the index it ends up at; appended marks it,
+// as a patch adds it with "-".
+type change struct {
+	op       string
+	path     []string
+	before   interface{}
+	after    interface{}
+	appended bool
+}
+
+// diffValues appends the changes turning from into to at path
+func diffValues(from, to interface{}, path []string, changes []change, opts diffOptions) []change {
+	if opts.ignored(path) || opts.withinTolerance(from, to) || reflect.DeepEqual(from, to) {
+		return changes
+	}
+
+	switch f := from.(type) {
+	case map[string]interface{}:
+		t, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(f) {
+			child := appendToken(path, key)
+			if value, ok := t[key]; ok {
+				changes = diffValues(f[key], value, child, changes, opts)
+			} else if !opts.ignored(child) {
+				changes = append(changes, change{op: "remove", path: child, before: f[key]})
+			}
+		}
+		for _, key := range sortedKeys(t) {
+			child := appendToken(path, key)
+			if _, ok := f[key]; !ok && !opts.ignored(child) {
+				changes = append(changes, change{op: "add", path: child, after: t[key]})
+			}
+		}
+		return changes
+	case []interface{}:
+		t, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		common := len(f)
+		if len(t) < common {
+			common = len(t)
+		}
+		for i := 0; i < common; i++ {
+			changes = diffValues(f[i], t[i], appendToken(path, strconv.Itoa(i)), changes, opts)
+		}
+		for i := len(f) - 1; i >= len(t); i-- {
+			if child := appendToken(path, strconv.Itoa(i)); !opts.ignored(child) {
+				changes = append(changes, change{op: "remove", path: child, before: f[i]})
+			}
+		}
+		for i := len(f); i < len(t); i++ {
+			if child := appendToken(path, strconv.Itoa(i)); !opts.ignored(child) {
+				changes = append(changes, change{op: "add", path: child, after: t[i], appended: true})
+			}
+		}
+		return changes
+	}
+	return append(changes, change{op: "replace", path: path, before: from, after: to})
+}
+
+// diffFormats are the output formats of gen-patch selected by --diff-format
+var diffFormats = []string{"patch", "json", "text"}
+
+// parseDiffFormat validates a --diff-format name
+func parseDiffFormat(name string) (string, error) {
+	name = strings.ToLower(name)
+	for _, format := range diffFormats {
+		if name == format {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown --diff-format %q (want %s)", name, strings.Join(diffFormats, ", "))
+}
+
+// writeDiff writes the differences between from and to in a --diff-format:
+// patch is an RFC 6902 JSON Patch, json an array of objects holding each
+// changed path with its old and new value, and text one line per change
+// marked +, - or ~
+func writeDiff(out io.Writer, from, to interface{}, opts diffOptions, format string) error {
+	switch format {
+	case "json":
+		report := []interface{}{}
+		for _, c := range diffValues(from, to, nil, nil, opts) {
+			entry := map[string]interface{}{"op": c.op, "path": formatPointer(c.path)}
+			if c.op != "add" {
+				entry["old"] = c.before
+			}
+			if c.op != "remove" {
+				entry["new"] = c.after
+			}
+			report = append(report, entry)
+		}
+		return printJSON(out, report)
+	case "text":
+		for _, c := range diffValues(from, to, nil, nil, opts) {
+			path := formatPointer(c.path)
+			if path == "" {
+				path = "(root)"
+			}
+			var line string
+			switch c.op {
+			case "add":
+				line = fmt.Sprintf("+ %s: %s", path, compactJSON(c.after))
+			case "remove":
+				line = fmt.Sprintf("- %s: %s", path, compactJSON(c.before))
+			default:
+				line = fmt.Sprintf("~ %s: %s -> %s", path, compactJSON(c.before), compactJSON(c.after))
+			}
+			if err := writeOutput(out, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return printJSON(out, generateJSONPatch(from, to, opts))
+	}
+}
+
+// appendToken returns a new path extended by one reference token
+func appendToken(path []string, token string) []string {
+	return append(append([]string{}, path...), token)
+}
+
+// patchOperation builds a single JSON Patch operation
+func patchOperation(op string, path []string, value interface{}, hasValue bool) map[string]interface{} {
+	operation := map[string]interface{}{"op": op, "path": formatPointer(path)}
+	if hasValue {
+		operation["value"] = value
+	}
+	return operation
+}
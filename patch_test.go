@@ -0,0 +1,604 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples from RFC 7386, appendix A
+	tests := []struct {
+		name     string
+		target   string
+		patch    string
+		expected string
+	}{
+		{name: "replace member", target: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{name: "add member", target: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{name: "null deletes", target: `{"a":"b"}`, patch: `{"a":null}`, expected: `{}`},
+		{name: "null deletes one of two", target: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{name: "array replaced", target: `{"a":["b"]}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{name: "value replaced by array", target: `{"a":"c"}`, patch: `{"a":["b"]}`, expected: `{"a":["b"]}`},
+		{name: "nested merge with deletion", target: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, expected: `{"a":{"b":"d"}}`},
+		{name: "array of objects replaced", target: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, expected: `{"a":[1]}`},
+		{name: "arrays replaced", target: `["a","b"]`, patch: `["c","d"]`, expected: `["c","d"]`},
+		{name: "object replaced by array", target: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+		{name: "replaced by null", target: `{"a":"foo"}`, patch: `null`, expected: `null`},
+		{name: "replaced by string", target: `{"a":"foo"}`, patch: `"bar"`, expected: `"bar"`},
+		{name: "null member kept in target", target: `{"e":null}`, patch: `{"a":1}`, expected: `{"a":1,"e":null}`},
+		{name: "array target becomes object", target: `[1,2]`, patch: `{"a":"b","c":null}`, expected: `{"a":"b"}`},
+		{name: "nested null in new member", target: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, expected: `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := parseJSON(tt.target)
+			if err != nil {
+				t.Fatalf("parseJSON(target) error = %v", err)
+			}
+			patch, err := parseJSON(tt.patch)
+			if err != nil {
+				t.Fatalf("parseJSON(patch) error = %v", err)
+			}
+			result, err := json.Marshal(mergePatch(target, patch))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("mergePatch() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergePatchLeavesTargetUnchanged(t *testing.T) {
+	target := map[string]interface{}{"a": map[string]interface{}{"b": 1.0}}
+	mergePatch(target, map[string]interface{}{"a": map[string]interface{}{"b": nil}})
+	if inner := target["a"].(map[string]interface{}); inner["b"] != 1.0 {
+		t.Errorf("mergePatch() modified the target: %v", target)
+	}
+}
+
+func TestRunApplyPatch(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"doc.json":   `{"a": 1, "b": {"c": 2}}`,
+		"patch.json": `{"b": {"c": null, "d": 3}}`,
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:   "inline inputs",
+			args:   []string{"apply-patch", `{"a": 1}`, `{"a": null, "b": 2}`},
+			stdout: "{\"b\":2}\n",
+		},
+		{
+			name:   "file inputs",
+			args:   []string{"-f", "apply-patch", filepath.Join(dir, "doc.json"), filepath.Join(dir, "patch.json")},
+			stdout: "{\"a\":1,\"b\":{\"d\":3}}\n",
+		},
+		{
+			name:     "missing patch",
+			args:     []string{"apply-patch", `{"a": 1}`},
+			exitCode: 1,
+		},
+		{
+			name:     "invalid patch",
+			args:     []string{"apply-patch", `{"a": 1}`, `{"a": }`},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "add object member",
+			doc:      `{"foo": "bar"}`,
+			patch:    `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			expected: `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:     "add array element",
+			doc:      `{"foo": ["bar", "baz"]}`,
+			patch:    `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			expected: `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:     "append to array",
+			doc:      `{"foo": ["bar"]}`,
+			patch:    `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			expected: `{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			name:     "add null value",
+			doc:      `{}`,
+			patch:    `[{"op": "add", "path": "/a", "value": null}]`,
+			expected: `{"a":null}`,
+		},
+		{
+			name:     "add replaces whole document",
+			doc:      `{"a": 1}`,
+			patch:    `[{"op": "add", "path": "", "value": [1]}]`,
+			expected: `[1]`,
+		},
+		{
+			name:     "remove object member",
+			doc:      `{"baz": "qux", "foo": "bar"}`,
+			patch:    `[{"op": "remove", "path": "/baz"}]`,
+			expected: `{"foo":"bar"}`,
+		},
+		{
+			name:     "remove array element",
+			doc:      `{"foo": ["bar", "qux", "baz"]}`,
+			patch:    `[{"op": "remove", "path": "/foo/1"}]`,
+			expected: `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:     "replace value",
+			doc:      `{"baz": "qux", "foo": "bar"}`,
+			patch:    `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			expected: `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:     "move value",
+			doc:      `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch:    `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			expected: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:     "move array element",
+			doc:      `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch:    `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			expected: `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:     "copy value",
+			doc:      `{"a": {"b": [1]}}`,
+			patch:    `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "add", "path": "/c/b/-", "value": 2}]`,
+			expected: `{"a":{"b":[1]},"c":{"b":[1,2]}}`,
+		},
+		{
+			name:     "passing test",
+			doc:      `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch:    `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			expected: `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:     "escaped pointer",
+			doc:      `{"/": 9, "~1": 10}`,
+			patch:    `[{"op": "test", "path": "/~01", "value": 10}, {"op": "remove", "path": "/~1"}]`,
+			expected: `{"~1":10}`,
+		},
+		{
+			name:    "failing test",
+			doc:     `{"baz": "qux"}`,
+			patch:   `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			wantErr: `operation 0 (test): test failed: value at "/baz" is "qux", want "bar"`,
+		},
+		{
+			name:    "failing test aborts later operations",
+			doc:     `{"a": 1}`,
+			patch:   `[{"op": "remove", "path": "/a"}, {"op": "test", "path": "/a", "value": 1}]`,
+			wantErr: "operation 1 (test)",
+		},
+		{
+			name:    "add to nonexistent parent",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			wantErr: "operation 0 (add)",
+		},
+		{
+			name:    "array index out of bounds",
+			doc:     `{"foo": [1]}`,
+			patch:   `[{"op": "add", "path": "/foo/2", "value": 2}]`,
+			wantErr: "out of range",
+		},
+		{
+			name:    "remove missing member",
+			doc:     `{}`,
+			patch:   `[{"op": "remove", "path": "/a"}]`,
+			wantErr: "no value",
+		},
+		{
+			name:    "replace missing member",
+			doc:     `{}`,
+			patch:   `[{"op": "replace", "path": "/a", "value": 1}]`,
+			wantErr: "no value",
+		},
+		{
+			name:    "move into own child",
+			doc:     `{"a": {"b": {}}}`,
+			patch:   `[{"op": "move", "from": "/a", "path": "/a/b/c"}]`,
+			wantErr: "into its own child",
+		},
+		{
+			name:    "missing value",
+			doc:     `{}`,
+			patch:   `[{"op": "add", "path": "/a"}]`,
+			wantErr: `missing "value"`,
+		},
+		{
+			name:    "unknown operation",
+			doc:     `{}`,
+			patch:   `[{"op": "merge", "path": ""}]`,
+			wantErr: "unknown operation",
+		},
+		{
+			name:    "patch not an array",
+			doc:     `{}`,
+			patch:   `{"op": "add"}`,
+			wantErr: "must be an array",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseJSON(tt.doc)
+			if err != nil {
+				t.Fatalf("parseJSON(doc) error = %v", err)
+			}
+			patch, err := parseJSON(tt.patch)
+			if err != nil {
+				t.Fatalf("parseJSON(patch) error = %v", err)
+			}
+
+			result, err := applyJSONPatch(doc, patch)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyJSONPatch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyJSONPatch() error = %v", err)
+			}
+			output, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("applyJSONPatch() = %s, want %s", output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "identical documents",
+			from:     `{"a": [1, {"b": null}]}`,
+			to:       `{"a": [1, {"b": null}]}`,
+			expected: `[]`,
+		},
+		{
+			name:     "changed value is replaced",
+			from:     `{"a": {"b": 1, "c": 2}}`,
+			to:       `{"a": {"b": 1, "c": 3}}`,
+			expected: `[{"op":"replace","path":"/a/c","value":3}]`,
+		},
+		{
+			name:     "members added and removed",
+			from:     `{"keep": 1, "old": 2}`,
+			to:       `{"keep": 1, "new": 3}`,
+			expected: `[{"op":"remove","path":"/old"},{"op":"add","path":"/new","value":3}]`,
+		},
+		{
+			name:     "type change is replaced",
+			from:     `{"a": [1]}`,
+			to:       `{"a": {"0": 1}}`,
+			expected: `[{"op":"replace","path":"/a","value":{"0":1}}]`,
+		},
+		{
+			name:     "array grows",
+			from:     `[1]`,
+			to:       `[1, 2, 3]`,
+			expected: `[{"op":"add","path":"/-","value":2},{"op":"add","path":"/-","value":3}]`,
+		},
+		{
+			name:     "array shrinks from the end",
+			from:     `[1, 2, 3]`,
+			to:       `[1]`,
+			expected: `[{"op":"remove","path":"/2"},{"op":"remove","path":"/1"}]`,
+		},
+		{
+			name:     "root replaced",
+			from:     `"a"`,
+			to:       `"b"`,
+			expected: `[{"op":"replace","path":"","value":"b"}]`,
+		},
+		{
+			name:     "keys needing escapes",
+			from:     `{"a/b": 1, "c~d": 1}`,
+			to:       `{"a/b": 2}`,
+			expected: `[{"op":"replace","path":"/a~1b","value":2},{"op":"remove","path":"/c~0d"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := parseJSON(tt.from)
+			if err != nil {
+				t.Fatalf("parseJSON(from) error = %v", err)
+			}
+			to, err := parseJSON(tt.to)
+			if err != nil {
+				t.Fatalf("parseJSON(to) error = %v", err)
+			}
+
+			patch := generateJSONPatch(from, to, diffOptions{})
+			output, err := json.Marshal(patch)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("generateJSONPatch() = %s, want %s", output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateJSONPatchRoundTrip(t *testing.T) {
+	pairs := [][2]string{
+		{`{"a": 1, "b": [1, 2, 3], "c": {"d": "x"}}`, `{"b": [1, 5], "c": {"d": "y", "e": [true]}, "f": null}`},
+		{`[{"id": 1}, {"id": 2}]`, `[{"id": 1, "tag": "x"}, {"id": 3}, {"id": 4}]`},
+		{`{"nested": [[1, 2], [3]]}`, `{"nested": [[1], [3, 4], []]}`},
+		{`{}`, `[]`},
+		{`null`, `{"a": 1}`},
+	}
+
+	for _, pair := range pairs {
+		from, err := parseJSON(pair[0])
+		if err != nil {
+			t.Fatalf("parseJSON(from) error = %v", err)
+		}
+		to, err := parseJSON(pair[1])
+		if err != nil {
+			t.Fatalf("parseJSON(to) error = %v", err)
+		}
+
+		// Round trip the patch through JSON, as a user would
+		patchJSON, err := json.Marshal(generateJSONPatch(from, to, diffOptions{}))
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		patch, err := parseJSON(string(patchJSON))
+		if err != nil {
+			t.Fatalf("parseJSON(patch) error = %v", err)
+		}
+
+		result, err := applyJSONPatch(deepCopy(from), patch)
+		if err != nil {
+			t.Fatalf("applyJSONPatch(%s) error = %v", patchJSON, err)
+		}
+		if !reflect.DeepEqual(result, to) {
+			t.Errorf("applying %s to %s = %v, want %s", patchJSON, pair[0], result, pair[1])
+		}
+	}
+}
+
+func TestGenerateJSONPatchIgnorePaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		ignore   []string
+		from     string
+		to       string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "differences only at ignored paths",
+			ignore:   []string{"/updatedAt", "/id"},
+			from:     `{"id": "a1", "name": "x", "updatedAt": "2024-01-01"}`,
+			to:       `{"id": "b2", "name": "x", "updatedAt": "2024-06-30"}`,
+			expected: `[]`,
+		},
+		{
+			name:     "everything below an ignored path",
+			ignore:   []string{"/meta"},
+			from:     `{"meta": {"rev": 1, "by": "a"}, "v": 1}`,
+			to:       `{"meta": {"rev": 2}, "v": 2}`,
+			expected: `[{"op":"replace","path":"/v","value":2}]`,
+		},
+		{
+			name:     "ignored members added and removed",
+			ignore:   []string{"/old", "/new"},
+			from:     `{"keep": 1, "old": 2}`,
+			to:       `{"keep": 1, "new": 3}`,
+			expected: `[]`,
+		},
+		{
+			name:     "ignored path inside array elements",
+			ignore:   []string{"/items/0/id", "/items/2"},
+			from:     `{"items": [{"id": 1, "n": "a"}]}`,
+			to:       `{"items": [{"id": 9, "n": "a"}, {"id": 2}, {"id": 3}]}`,
+			expected: `[{"op":"add","path":"/items/-","value":{"id":2}}]`,
+		},
+		{
+			name:     "escaped key",
+			ignore:   []string{"/a~1b"},
+			from:     `{"a/b": 1}`,
+			to:       `{"a/b": 2}`,
+			expected: `[]`,
+		},
+		{
+			name:    "invalid pointer",
+			ignore:  []string{"id"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := newDiffOptions(tt.ignore, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newDiffOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			from, _ := parseJSON(tt.from)
+			to, _ := parseJSON(tt.to)
+			output, err := json.Marshal(generateJSONPatch(from, to, opts))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("generateJSONPatch() = %s, want %s", output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateJSONPatchFloatTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance float64
+		from      string
+		to        string
+		expected  string
+	}{
+		{
+			name:     "exact by default",
+			from:     `{"price": 0.30000000000000004}`,
+			to:       `{"price": 0.3}`,
+			expected: `[{"op":"replace","path":"/price","value":0.3}]`,
+		},
+		{
+			name:      "within the tolerance",
+			tolerance: 1e-9,
+			from:      `{"price": 0.30000000000000004, "rates": [1.0001, 2.5]}`,
+			to:        `{"price": 0.3, "rates": [1.00010000001, 2.5]}`,
+			expected:  `[]`,
+		},
+		{
+			name:      "beyond the tolerance",
+			tolerance: 0.01,
+			from:      `{"temp": 20.5}`,
+			to:        `{"temp": 20.52}`,
+			expected:  `[{"op":"replace","path":"/temp","value":20.52}]`,
+		},
+		{
+			name:      "integers compare exactly",
+			tolerance: 5,
+			from:      `{"count": 10, "mixed": 10}`,
+			to:        `{"count": 11, "mixed": 10.5}`,
+			expected:  `[{"op":"replace","path":"/count","value":11}]`,
+		},
+		{
+			name:      "not applied across types",
+			tolerance: 1,
+			from:      `{"v": 0.5}`,
+			to:        `{"v": "0.5"}`,
+			expected:  `[{"op":"replace","path":"/v","value":"0.5"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := newDiffOptions(nil, tt.tolerance)
+			if err != nil {
+				t.Fatalf("newDiffOptions() error = %v", err)
+			}
+			from, _ := parseJSON(tt.from)
+			to, _ := parseJSON(tt.to)
+			output, err := json.Marshal(generateJSONPatch(from, to, opts))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("generateJSONPatch() = %s, want %s", output, tt.expected)
+			}
+		})
+	}
+
+	if _, err := newDiffOptions(nil, -0.1); err == nil {
+		t.Error("newDiffOptions() expected error for a negative tolerance")
+	}
+}
+
+func TestRunGenPatchDiffFormat(t *testing.T) {
+	from := `{"name": "api", "port": 80, "tags": ["a"], "tls": {"on": false}}`
+	to := `{"name": "web", "tags": ["a", "b", {"x": 1}], "tls": {"on": true}, "zone": null}`
+
+	tests := []struct {
+		format   string
+		exitCode int
+		expected string
+	}{
+		{
+			format:   "patch",
+			expected: `[{"op":"replace","path":"/name","value":"web"},{"op":"remove","path":"/port"},{"op":"add","path":"/tags/-","value":"b"},{"op":"add","path":"/tags/-","value":{"x":1}},{"op":"replace","path":"/tls/on","value":true},{"op":"add","path":"/zone","value":null}]` + "\n",
+		},
+		{
+			format:   "json",
+			expected: `[{"new":"web","old":"api","op":"replace","path":"/name"},{"old":80,"op":"remove","path":"/port"},{"new":"b","op":"add","path":"/tags/1"},{"new":{"x":1},"op":"add","path":"/tags/2"},{"new":true,"old":false,"op":"replace","path":"/tls/on"},{"new":null,"op":"add","path":"/zone"}]` + "\n",
+		},
+		{
+			format: "text",
+			expected: `~ /name: "api" -> "web"` + "\n" +
+				`- /port: 80` + "\n" +
+				`+ /tags/1: "b"` + "\n" +
+				`+ /tags/2: {"x":1}` + "\n" +
+				`~ /tls/on: false -> true` + "\n" +
+				`+ /zone: null` + "\n",
+		},
+		{format: "unified", exitCode: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run([]string{"--diff-format", tt.format, "gen-patch", from, to}, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunGenPatchDiffFormatIdentical(t *testing.T) {
+	for format, expected := range map[string]string{"patch": "[]\n", "json": "[]\n", "text": "", "TEXT": ""} {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--diff-format", format, "gen-patch", `{"a": 1}`, `{"a": 1.0}`}, nil, &stdout, &stderr); code != 0 {
+			t.Fatalf("run() with --diff-format %s = %d, want 0 (stderr: %s)", format, code, stderr.String())
+		}
+		if stdout.String() != expected {
+			t.Errorf("run() with --diff-format %s stdout = %q, want %q", format, stdout.String(), expected)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// pipelineStages maps the --pipeline stage names to their transforms. The
+// encoded output is always compact with object keys sorted, so the
+// "compact" and "sort-keys" stages leave the document unchanged; they are
+// accepted so that pipelines can spell out the intended output.
+var pipelineStages = map[string]func(interface{}) (interface{}, error){
+	"compact":      keepValue,
+	"sort-keys":    keepValue,
+	"drop-nulls":   func(v interface{}) (interface{}, error) { return dropNulls(v), nil },
+	"js-numbers":   func(v interface{}) (interface{}, error) { return jsNumbers(v), nil },
+	"resolve-refs": resolveRefs,
+	"trim-keys":    func(v interface{}) (interface{}, error) { return trimKeys(v, "error") },
+}
+
+func keepValue(value interface{}) (interface{}, error) {
+	return value, nil
+}
+
+// runPipeline applies a comma-separated sequence of stages in order
+func runPipeline(value interface{}, pipeline string) (interface{}, error) {
+	for _, name := range strings.Split(pipeline, ",") {
+		name = strings.TrimSpace(name)
+		stage, ok := pipelineStages[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown pipeline stage %q (want %s)", name, strings.Join(pipelineStageNames(), ", "))
+		}
+		var err error
+		if value, err = stage(value); err != nil {
+			return nil, fmt.Errorf("pipeline stage %s: %v", name, err)
+		}
+	}
+	return value, nil
+}
+
+func pipelineStageNames() []string {
+	names := make([]string, 0, len(pipelineStages))
+	for name := range pipelineStages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dropNulls removes null object members and array elements at any depth
+func dropNulls(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		kept := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			if child != nil {
+				kept[key] = dropNulls(child)
+			}
+		}
+		return kept
+	case []interface{}:
+		kept := make([]interface{}, 0, len(v))
+		for _, child := range v {
+			if child != nil {
+				kept = append(kept, dropNulls(child))
+			}
+		}
+		return kept
+	default:
+		return value
+	}
+}
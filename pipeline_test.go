@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDropNulls(t *testing.T) {
+	var input interface{}
+	if err := json.Unmarshal([]byte(`{"a": null, "b": [null, 1, {"c": null}], "d": {"e": null}}`), &input); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	result, err := json.Marshal(dropNulls(input))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	expected := `{"b":[1,{}],"d":{}}`
+	if string(result) != expected {
+		t.Errorf("dropNulls() = %s, want %s", result, expected)
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		pipeline string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "single stage",
+			input:    `{"a": null, "b": 1}`,
+			pipeline: "drop-nulls",
+			expected: `{"b":1}`,
+		},
+		{
+			name:     "stages run in order",
+			input:    `{" a ": null, "a": 1}`,
+			pipeline: "drop-nulls, trim-keys",
+			expected: `{"a":1}`,
+		},
+		{
+			name:     "order matters",
+			input:    `{" a ": null, "a": 1}`,
+			pipeline: "trim-keys,drop-nulls",
+			wantErr:  true,
+		},
+		{
+			name:     "formatting stages keep the value",
+			input:    `{"b": [1, 2], "a": -0}`,
+			pipeline: "sort-keys,compact,js-numbers",
+			expected: `{"a":0,"b":[1,2]}`,
+		},
+		{
+			name:     "unknown stage",
+			input:    `{}`,
+			pipeline: "sort-keys,shout",
+			wantErr:  true,
+		},
+		{
+			name:     "empty stage",
+			input:    `{}`,
+			pipeline: "sort-keys,",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input interface{}
+			if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			result, err := runPipeline(input, tt.pipeline)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runPipeline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(encoded) != tt.expected {
+				t.Errorf("runPipeline() = %s, want %s", encoded, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunPipelineMatchesIndividualStages(t *testing.T) {
+	input := `{" name ": "x", "tags": [null, "a"], "size": -0, "ref": {"$ref": "#/tags"}, "gone": null}`
+	pipeline := []string{"resolve-refs", "drop-nulls", "trim-keys", "js-numbers", "sort-keys", "compact"}
+
+	// Run the stages one invocation at a time, feeding each output back in
+	stepped := input
+	for _, stage := range pipeline {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--pipeline", stage, "encode", stepped}, nil, &stdout, &stderr); code != 0 {
+			t.Fatalf("run(%s) = %d, want 0 (stderr: %s)", stage, code, stderr.String())
+		}
+		if err := json.Unmarshal(stdout.Bytes(), &stepped); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--pipeline", strings.Join(pipeline, ","), "encode", input}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	var combined string
+	if err := json.Unmarshal(stdout.Bytes(), &combined); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if combined != stepped {
+		t.Errorf("pipeline = %s, want %s from the individual stages", combined, stepped)
+	}
+}
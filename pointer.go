@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped reference
+// tokens. The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must be empty or start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// resolvePointer returns the value a JSON Pointer refers to within a document
+func resolvePointer(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for i, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("JSON pointer %q: key %q not found", pointer, token)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, fmt.Errorf("JSON pointer %q: %v", pointer, err)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("JSON pointer %q: cannot index into %s at %q", pointer, jsonType(current), formatPointer(tokens[:i]))
+		}
+	}
+	return current, nil
+}
+
+// pathExists reports whether a JSON Pointer resolves to a value within a
+// document. A null value counts as present unless nullAsAbsent is set.
+func pathExists(doc interface{}, pointer string, nullAsAbsent bool) bool {
+	value, err := resolvePointer(doc, pointer)
+	return err == nil && (value != nil || !nullAsAbsent)
+}
+
+// arrayIndex validates an array reference token against an array length
+func arrayIndex(token string, length int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index >= length {
+		return 0, fmt.Errorf("array index %s out of range", token)
+	}
+	return index, nil
+}
+
+// formatPointer joins reference tokens back into an escaped JSON Pointer
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return b.String()
+}
+
+// jsonType names the JSON type of a parsed value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
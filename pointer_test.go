@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestResolvePointer(t *testing.T) {
+	doc, err := parseJSON(`{"a": {"b/c": [10, {"d~e": "found"}]}, "": "empty key"}`)
+	if err != nil {
+		t.Fatalf("parseJSON() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pointer  string
+		expected interface{}
+		wantErr  bool
+	}{
+		{name: "root", pointer: "", expected: doc},
+		{name: "escaped slash", pointer: "/a/b~1c/0", expected: 10.0},
+		{name: "escaped tilde", pointer: "/a/b~1c/1/d~0e", expected: "found"},
+		{name: "empty key", pointer: "/", expected: "empty key"},
+		{name: "missing key", pointer: "/missing", wantErr: true},
+		{name: "index out of range", pointer: "/a/b~1c/2", wantErr: true},
+		{name: "leading zero index", pointer: "/a/b~1c/01", wantErr: true},
+		{name: "non-numeric index", pointer: "/a/b~1c/-", wantErr: true},
+		{name: "index into scalar", pointer: "/a/b~1c/0/x", wantErr: true},
+		{name: "missing leading slash", pointer: "a", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := resolvePointer(doc, tt.pointer)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolvePointer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !equalJSON(result, tt.expected) {
+				t.Errorf("resolvePointer() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatPointer(t *testing.T) {
+	tokens, err := parsePointer("/a~1b/c~0d/0")
+	if err != nil {
+		t.Fatalf("parsePointer() error = %v", err)
+	}
+	if got := formatPointer(tokens); got != "/a~1b/c~0d/0" {
+		t.Errorf("formatPointer() = %v, want %v", got, "/a~1b/c~0d/0")
+	}
+}
+
+func TestPathExists(t *testing.T) {
+	doc, err := parseJSON(`{"a": {"b": [1, null]}, "n": null}`)
+	if err != nil {
+		t.Fatalf("parseJSON() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		pointer      string
+		exists       bool
+		existsNoNull bool
+	}{
+		{name: "present", pointer: "/a/b/0", exists: true, existsNoNull: true},
+		{name: "root", pointer: "", exists: true, existsNoNull: true},
+		{name: "null member", pointer: "/n", exists: true, existsNoNull: false},
+		{name: "null element", pointer: "/a/b/1", exists: true, existsNoNull: false},
+		{name: "absent key", pointer: "/x", exists: false, existsNoNull: false},
+		{name: "index out of range", pointer: "/a/b/2", exists: false, existsNoNull: false},
+		{name: "below a scalar", pointer: "/a/b/0/c", exists: false, existsNoNull: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pathExists(doc, tt.pointer, false); got != tt.exists {
+				t.Errorf("pathExists(%q) = %v, want %v", tt.pointer, got, tt.exists)
+			}
+			if got := pathExists(doc, tt.pointer, true); got != tt.existsNoNull {
+				t.Errorf("pathExists(%q) with null as absent = %v, want %v", tt.pointer, got, tt.existsNoNull)
+			}
+		})
+	}
+}
+
+func TestRunExists(t *testing.T) {
+	input := `{"name": "api", "tls": null}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+	}{
+		{name: "present", args: []string{"--path", "/name", "exists", input}, exitCode: 0},
+		{name: "null", args: []string{"--path", "/tls", "exists", input}, exitCode: 0},
+		{name: "null as absent", args: []string{"--treat-null-as-absent", "--path", "/tls", "exists", input}, exitCode: exitAssertionFailed},
+		{name: "absent", args: []string{"--path", "/port", "exists", input}, exitCode: exitAssertionFailed},
+		{name: "invalid pointer", args: []string{"--path", "port", "exists", input}, exitCode: 1},
+		{name: "invalid document", args: []string{"--path", "/port", "exists", `{`}, exitCode: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("run() stdout = %q, want nothing", stdout.String())
+			}
+			if tt.exitCode != 1 && stderr.Len() != 0 {
+				t.Errorf("run() stderr = %q, want nothing", stderr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// prettyOptions controls the layout written by the pretty command
+type prettyOptions struct {
+	// indent is added once per nesting level
+	indent string
+	// inlineArrays keeps arrays of scalars on one line, unless they have
+	// more than inlineArrayMax elements when that is positive
+	inlineArrays   bool
+	inlineArrayMax int
+	// collapseBelow, when positive, replaces objects and arrays that many
+	// levels below the root with a summary such as {…3 keys}
+	collapseBelow int
+	// indentLevels, when positive, writes objects and arrays that many
+	// levels below the root on one line
+	indentLevels int
+}
+
+// jsonNode is a JSON value with its scalars and member names kept as
+// written
+type jsonNode struct {
+	// kind is '{' or '[' for objects and arrays, and 0 for scalars
+	kind     byte
+	text     string
+	keys     []string
+	children []*jsonNode
+}
+
+// parseJSONNode reads the value at the start of valid compact JSON text,
+// as written by json.Compact, and returns it with the rest of the text
+func parseJSONNode(s string) (*jsonNode, string) {
+	switch s[0] {
+	case '{', '[':
+		node := &jsonNode{kind: s[0]}
+		end := closingBracket(node.kind)
+		s = s[1:]
+		for s[0] != end {
+			if node.kind == '{' {
+				n := stringLength(s)
+				node.keys = append(node.keys, s[:n])
+				s = s[n+1:]
+			}
+			child, rest := parseJSONNode(s)
+			node.children = append(node.children, child)
+			s = strings.TrimPrefix(rest, ",")
+		}
+		return node, s[1:]
+	case '"':
+		n := stringLength(s)
+		return &jsonNode{text: s[:n]}, s[n:]
+	default:
+		n := strings.IndexAny(s, ",]}")
+		if n < 0 {
+			n = len(s)
+		}
+		return &jsonNode{text: s[:n]}, s[n:]
+	}
+}
+
+// stringLength returns the length of the string literal s starts with
+func stringLength(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func closingBracket(kind byte) byte {
+	if kind == '{' {
+		return '}'
+	}
+	return ']'
+}
+
+// write lays out node, found depth levels below the root
+func (o prettyOptions) write(b *strings.Builder, node *jsonNode, depth int) {
+	if o.collapsed(node, depth) {
+		b.WriteString(collapsedNode(node))
+		return
+	}
+	if node.kind == 0 || len(node.children) == 0 || o.inline(node, depth) {
+		o.writeInline(b, node, depth)
+		return
+	}
+	b.WriteByte(node.kind)
+	for i, child := range node.children {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+		b.WriteString(strings.Repeat(o.indent, depth+1))
+		if node.kind == '{' {
+			b.WriteString(node.keys[i])
+			b.WriteString(": ")
+		}
+		o.write(b, child, depth+1)
+	}
+	b.WriteByte('\n')
+	b.WriteString(strings.Repeat(o.indent, depth))
+	b.WriteByte(closingBracket(node.kind))
+}
+
+// collapsed reports whether a container is summarized by --collapse-below
+func (o prettyOptions) collapsed(node *jsonNode, depth int) bool {
+	return node.kind != 0 && len(node.children) > 0 && o.collapseBelow > 0 && depth >= o.collapseBelow
+}
+
+// inline reports whether a container is written on one line
+func (o prettyOptions) inline(node *jsonNode, depth int) bool {
+	if o.indentLevels > 0 && depth >= o.indentLevels {
+		return true
+	}
+	if node.kind != '[' || !o.inlineArrays {
+		return false
+	}
+	if o.inlineArrayMax > 0 && len(node.children) > o.inlineArrayMax {
+		return false
+	}
+	for _, child := range node.children {
+		if child.kind != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// writeInline writes node on one line, with a space after each comma and
+// colon
+func (o prettyOptions) writeInline(b *strings.Builder, node *jsonNode, depth int) {
+	if o.collapsed(node, depth) {
+		b.WriteString(collapsedNode(node))
+		return
+	}
+	if node.kind == 0 {
+		b.WriteString(node.text)
+		return
+	}
+	b.WriteByte(node.kind)
+	for i, child := range node.children {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if node.kind == '{' {
+			b.WriteString(node.keys[i])
+			b.WriteString(": ")
+		}
+		o.writeInline(b, child, depth+1)
+	}
+	b.WriteByte(closingBracket(node.kind))
+}
+
+// collapsedNode summarizes a container as {…3 keys} or […10 items]
+func collapsedNode(node *jsonNode) string {
+	n := len(node.children)
+	noun := "items"
+	if node.kind == '{' {
+		noun = "keys"
+	}
+	if n == 1 {
+		noun = strings.TrimSuffix(noun, "s")
+	}
+	return fmt.Sprintf("%c…%d %s%c", node.kind, n, noun, closingBracket(node.kind))
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestPrettyJSONMatchesIndent(t *testing.T) {
+	inputs := []string{
+		`{"b":1.50,"a":["é\/\"]",1e3,[]],"n":{"x":{},"y":[{"z":null}]}}`,
+		`[[1,[2,[3]]],{"k,}":"v]"},"",false]`,
+		`"text"`,
+		`{}`,
+	}
+	for _, input := range inputs {
+		var expected bytes.Buffer
+		if err := json.Indent(&expected, []byte(input), "", "  "); err != nil {
+			t.Fatalf("json.Indent() error = %v", err)
+		}
+		result, err := prettyJSON(input, prettyOptions{indent: "  "})
+		if err != nil {
+			t.Fatalf("prettyJSON() error = %v", err)
+		}
+		if result != expected.String() {
+			t.Errorf("prettyJSON(%s) = %q, want %q", input, result, expected.String())
+		}
+	}
+}
+
+func TestPrettyJSONInlineArrays(t *testing.T) {
+	input := `{"tags":["a","b","c"],"matrix":[[1,2],[3,4]],"mixed":[1,{"x":2}],"empty":[]}`
+
+	tests := []struct {
+		name     string
+		opts     prettyOptions
+		expected string
+	}{
+		{
+			name: "without --inline-arrays",
+			opts: prettyOptions{indent: "  "},
+			expected: `{
+  "tags": [
+    "a",
+    "b",
+    "c"
+  ],
+  "matrix": [
+    [
+      1,
+      2
+    ],
+    [
+      3,
+      4
+    ]
+  ],
+  "mixed": [
+    1,
+    {
+      "x": 2
+    }
+  ],
+  "empty": []
+}`,
+		},
+		{
+			name: "with --inline-arrays",
+			opts: prettyOptions{indent: "  ", inlineArrays: true},
+			expected: `{
+  "tags": ["a", "b", "c"],
+  "matrix": [
+    [1, 2],
+    [3, 4]
+  ],
+  "mixed": [
+    1,
+    {
+      "x": 2
+    }
+  ],
+  "empty": []
+}`,
+		},
+		{
+			name: "longer arrays wrap with --inline-array-max",
+			opts: prettyOptions{indent: "  ", inlineArrays: true, inlineArrayMax: 2},
+			expected: `{
+  "tags": [
+    "a",
+    "b",
+    "c"
+  ],
+  "matrix": [
+    [1, 2],
+    [3, 4]
+  ],
+  "mixed": [
+    1,
+    {
+      "x": 2
+    }
+  ],
+  "empty": []
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := prettyJSON(input, tt.opts)
+			if err != nil {
+				t.Fatalf("prettyJSON() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("prettyJSON() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunPrettyInlineArrays(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:   "inline",
+			args:   []string{"pretty", "--inline-arrays", `{"a":[1,2,3]}`},
+			stdout: "{\n  \"a\": [1, 2, 3]\n}\n",
+		},
+		{
+			name:   "wrapped above the maximum",
+			args:   []string{"pretty", "--inline-arrays", "--inline-array-max", "2", `{"a":[1,2,3]}`},
+			stdout: "{\n  \"a\": [\n    1,\n    2,\n    3\n  ]\n}\n",
+		},
+		{
+			name:     "maximum without --inline-arrays",
+			args:     []string{"pretty", "--inline-array-max", "2", `[1]`},
+			exitCode: 1,
+		},
+		{
+			name:     "maximum below one",
+			args:     []string{"pretty", "--inline-arrays", "--inline-array-max", "0", `[1]`},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
+
+func TestPrettyJSONCollapseBelow(t *testing.T) {
+	input := `{"id":7,"user":{"name":"a","roles":["x","y"],"prefs":{"k":1}},"items":[1,2,3,4,5,6,7,8,9,10],"tags":[],"one":[{"a":1}]}`
+
+	tests := []struct {
+		depth    int
+		expected string
+	}{
+		{
+			depth: 1,
+			expected: `{
+  "id": 7,
+  "user": {…3 keys},
+  "items": […10 items],
+  "tags": [],
+  "one": […1 item]
+}`,
+		},
+		{
+			depth: 2,
+			expected: `{
+  "id": 7,
+  "user": {
+    "name": "a",
+    "roles": […2 items],
+    "prefs": {…1 key}
+  },
+  "items": [
+    1,
+    2,
+    3,
+    4,
+    5,
+    6,
+    7,
+    8,
+    9,
+    10
+  ],
+  "tags": [],
+  "one": [
+    {…1 key}
+  ]
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("depth %d", tt.depth), func(t *testing.T) {
+			result, err := prettyJSON(input, prettyOptions{indent: "  ", collapseBelow: tt.depth})
+			if err != nil {
+				t.Fatalf("prettyJSON() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("prettyJSON() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunPrettyCollapseBelow(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"pretty", "--collapse-below", "1", "--inline-arrays", `[[1,2],{"a":1},3]`}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if expected := "[\n  […2 items],\n  {…1 key},\n  3\n]\n"; stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"pretty", "--collapse-below", "0", `[1]`}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("run() with --collapse-below 0 = %d, want 1", code)
+	}
+}
+
+func TestPrettyJSONIndentLevels(t *testing.T) {
+	input := `{"id":7,"user":{"name":"a","roles":["x","y"],"prefs":{"k":{"deep":true}}},"tags":[]}`
+
+	tests := []struct {
+		levels   int
+		expected string
+	}{
+		{
+			levels: 1,
+			expected: `{
+  "id": 7,
+  "user": {"name": "a", "roles": ["x", "y"], "prefs": {"k": {"deep": true}}},
+  "tags": []
+}`,
+		},
+		{
+			levels: 2,
+			expected: `{
+  "id": 7,
+  "user": {
+    "name": "a",
+    "roles": ["x", "y"],
+    "prefs": {"k": {"deep": true}}
+  },
+  "tags": []
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d levels", tt.levels), func(t *testing.T) {
+			result, err := prettyJSON(input, prettyOptions{indent: "  ", indentLevels: tt.levels})
+			if err != nil {
+				t.Fatalf("prettyJSON() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("prettyJSON() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunPrettyIndentLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:   "one level",
+			args:   []string{"pretty", "--indent-levels", "1", `[{"a":[1,2]},3]`},
+			stdout: "[\n  {\"a\": [1, 2]},\n  3\n]\n",
+		},
+		{
+			name:   "collapsed inside a one-line value",
+			args:   []string{"pretty", "--indent-levels", "1", "--collapse-below", "2", `[{"a":[1,2]},3]`},
+			stdout: "[\n  {\"a\": […2 items]},\n  3\n]\n",
+		},
+		{
+			name:     "zero levels",
+			args:     []string{"pretty", "--indent-levels", "0", `[1]`},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
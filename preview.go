@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// previewInput describes the input received, showing the first and last n
+// bytes escaped as a Go string literal. Input of up to 2n bytes is shown
+// whole.
+func previewInput(input string, n int) string {
+	if len(input) <= 2*n {
+		return fmt.Sprintf("received %d bytes: %q", len(input), input)
+	}
+	return fmt.Sprintf("received %d bytes: first %d %q, last %d %q",
+		len(input), n, input[:n], n, input[len(input)-n:])
+}
+
+// withInputPreview appends a preview of the input to an error when
+// --preview-bytes is set
+func withInputPreview(err error, input string, n int) error {
+	if n <= 0 {
+		return err
+	}
+	return fmt.Errorf("%v (%s)", err, previewInput(input, n))
+}
+
+// documentError adds the input preview to an error from parsing a JSON
+// document, but not to errors about input that is valid JSON, such as
+// exceeded limits
+func documentError(err error, input string, n int) error {
+	if json.Valid([]byte(input)) {
+		return err
+	}
+	return withInputPreview(err, input, n)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPreviewInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		n        int
+		expected string
+	}{
+		{
+			name:     "short input shown whole",
+			input:    "<p>",
+			n:        4,
+			expected: `received 3 bytes: "<p>"`,
+		},
+		{
+			name:     "long input shows both ends",
+			input:    "<html>502 Bad Gateway</html>",
+			n:        6,
+			expected: `received 28 bytes: first 6 "<html>", last 6 "/html>"`,
+		},
+		{
+			name:     "control characters escaped",
+			input:    "\x00\x01{\n\t}\xff",
+			n:        2,
+			expected: `received 7 bytes: first 2 "\x00\x01", last 2 "}\xff"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := previewInput(tt.input, tt.n); got != tt.expected {
+				t.Errorf("previewInput() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDocumentError(t *testing.T) {
+	err := errors.New("failed")
+
+	if got := documentError(err, "[1, 2]", 4); got.Error() != "failed" {
+		t.Errorf("documentError() = %v, want no preview for valid JSON", got)
+	}
+	if got := documentError(err, "oops", 0); got.Error() != "failed" {
+		t.Errorf("documentError() = %v, want no preview when disabled", got)
+	}
+	if got := documentError(err, "oops", 4); got.Error() != `failed (received 4 bytes: "oops")` {
+		t.Errorf("documentError() = %v, want preview", got)
+	}
+}
+
+func TestRunPreviewBytes(t *testing.T) {
+	input := "<!DOCTYPE html><html><body>Service Unavailable</body></html>"
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+		noPreview  bool
+	}{
+		{
+			name:       "encode",
+			args:       []string{"--preview-bytes", "9", "encode", input},
+			wantStderr: `first 9 "<!DOCTYPE", last 9 "y></html>"`,
+		},
+		{
+			name:       "decode",
+			args:       []string{"--preview-bytes", "4", "decode", input},
+			wantStderr: `first 4 "<!DO", last 4 "tml>"`,
+		},
+		{
+			name:      "off by default",
+			args:      []string{"encode", input},
+			noPreview: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != 1 {
+				t.Errorf("run() = %d, want 1", code)
+			}
+			if tt.noPreview {
+				if strings.Contains(stderr.String(), "received") {
+					t.Errorf("run() stderr = %q, want no preview", stderr.String())
+				}
+				return
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// project extracts the values at the comma-separated JSON Pointers in
+// fields into a flat object keyed by the last segment of each pointer.
+// Fields missing from the document are null, or left out when omitMissing
+// is set.
+func project(doc interface{}, fields string, omitMissing bool) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	source := make(map[string]string)
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		tokens, err := parsePointer(field)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("field pointers must name a member, got %q", field)
+		}
+		name := tokens[len(tokens)-1]
+		if previous, ok := source[name]; ok {
+			return nil, fmt.Errorf("fields %q and %q would both be named %q", previous, field, name)
+		}
+		source[name] = field
+
+		value, err := resolvePointer(doc, field)
+		if err != nil {
+			if omitMissing {
+				continue
+			}
+			value = nil
+		}
+		result[name] = value
+	}
+	return result, nil
+}
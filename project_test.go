@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	doc := `{"id": 7, "user": {"name": "Ann", "tags": ["a", "b"], "address": {"city": "Oslo", "zip": null}}}`
+
+	tests := []struct {
+		name        string
+		fields      string
+		omitMissing bool
+		expected    string
+		wantErr     bool
+	}{
+		{
+			name:     "nested fields",
+			fields:   "/id,/user/name,/user/address/city",
+			expected: `{"city":"Oslo","id":7,"name":"Ann"}`,
+		},
+		{
+			name:     "array element and whole subtree",
+			fields:   "/user/tags/1, /user/address",
+			expected: `{"1":"b","address":{"city":"Oslo","zip":null}}`,
+		},
+		{
+			name:     "missing fields become null",
+			fields:   "/id,/plan,/user/age,/id/x",
+			expected: `{"age":null,"id":7,"plan":null,"x":null}`,
+		},
+		{
+			name:        "missing fields omitted",
+			fields:      "/id,/plan,/user/address/zip",
+			omitMissing: true,
+			expected:    `{"id":7,"zip":null}`,
+		},
+		{
+			name:    "duplicate field names",
+			fields:  "/id,/user/id",
+			wantErr: true,
+		},
+		{
+			name:    "invalid pointer",
+			fields:  "id",
+			wantErr: true,
+		},
+		{
+			name:    "root pointer",
+			fields:  "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(doc)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			record, err := project(data, tt.fields, tt.omitMissing)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("project() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			result, err := json.Marshal(record)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("project() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
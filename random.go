@@ -0,0 +1,12 @@
+package main
+
+import "math/rand/v2"
+
+// rng is the random number generator used by the randomized commands. Each
+// run draws a fresh seed unless --seed fixes it.
+var rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+
+// seedRandom makes the output of the randomized commands reproducible
+func seedRandom(seed int64) {
+	rng = rand.New(rand.NewPCG(uint64(seed), 0))
+}
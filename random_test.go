@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeedRandom(t *testing.T) {
+	draw := func(seed int64) []int {
+		seedRandom(seed)
+		values := make([]int, 8)
+		for i := range values {
+			values[i] = rng.IntN(1000)
+		}
+		return values
+	}
+
+	first, second := draw(42), draw(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("seedRandom(42) draws %v then %v, want identical", first, second)
+	}
+	if other := draw(43); reflect.DeepEqual(first, other) {
+		t.Errorf("seedRandom(43) draws %v, want different from seed 42", other)
+	}
+}
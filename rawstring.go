@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// unescapeString resolves the JSON escapes in a string literal and returns
+// the raw text. Unlike decode, the text is not required to be JSON itself.
+// Input without surrounding quotes is taken as the body of the literal.
+func unescapeString(input string) (string, error) {
+	literal := input
+	if !strings.HasPrefix(literal, `"`) {
+		literal = `"` + literal + `"`
+	}
+
+	var text string
+	if err := json.Unmarshal([]byte(literal), &text); err != nil {
+		return "", fmt.Errorf("invalid JSON string: %v", err)
+	}
+	return text, nil
+}
+
+// escapeString produces the JSON string literal for arbitrary text, which
+// need not be JSON. Control characters use JSON escapes, so the result is
+// always a valid JSON string; other characters, including non-ASCII, are
+// kept as they are.
+func escapeString(text string) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(text); err != nil {
+		return "", fmt.Errorf("failed to escape string: %v", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestUnescapeString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "plain text that is not JSON",
+			input:    `"hello world"`,
+			expected: "hello world",
+		},
+		{
+			name:     "newlines tabs and quotes",
+			input:    `"line one\nline\ttwo \"quoted\""`,
+			expected: "line one\nline\ttwo \"quoted\"",
+		},
+		{
+			name:     "unicode escapes",
+			input:    `"caf\u00e9 \ud83d\ude00"`,
+			expected: "café 😀",
+		},
+		{
+			name:     "escaped JSON is not validated",
+			input:    `"{\"key\": "`,
+			expected: `{"key": `,
+		},
+		{
+			name:     "unquoted body",
+			input:    `a\\b\/c`,
+			expected: `a\b/c`,
+		},
+		{
+			name:    "invalid escape",
+			input:   `"bad \q"`,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated literal",
+			input:   `"open`,
+			wantErr: true,
+		},
+		{
+			name:    "unescaped quote in body",
+			input:   `say "hi"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := unescapeString(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unescapeString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("unescapeString() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEscapeString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			input:    "hello",
+			expected: `"hello"`,
+		},
+		{
+			name:     "newlines and tabs",
+			input:    "a\nb\tc\r",
+			expected: `"a\nb\tc\r"`,
+		},
+		{
+			name:     "quotes and backslashes",
+			input:    `say "hi" \ bye`,
+			expected: `"say \"hi\" \\ bye"`,
+		},
+		{
+			name:     "unicode kept",
+			input:    "café 😀",
+			expected: `"café 😀"`,
+		},
+		{
+			name:     "other control characters",
+			input:    "\x00\x1f",
+			expected: `"\u0000\u001f"`,
+		},
+		{
+			name:     "html characters not escaped",
+			input:    "<a & b>",
+			expected: `"<a & b>"`,
+		},
+		{
+			name:     "not valid JSON",
+			input:    `{"key": `,
+			expected: `"{\"key\": "`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := escapeString(tt.input)
+			if err != nil {
+				t.Fatalf("escapeString() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("escapeString() = %s, want %s", result, tt.expected)
+			}
+
+			text, err := unescapeString(result)
+			if err != nil {
+				t.Fatalf("unescapeString() error = %v", err)
+			}
+			if text != tt.input {
+				t.Errorf("unescapeString(escapeString()) = %q, want %q", text, tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// recoverPrefix cuts invalid JSON back to its longest valid prefix of whole
+// top-level values: the complete elements of a root array, the complete
+// members of a root object, or a complete root value followed by junk. The
+// original text is kept, so numbers and escapes are unchanged. It returns
+// the recovered JSON, the offset at which the input was cut and how many
+// elements or members were kept.
+func recoverPrefix(input string) (string, int64, int, error) {
+	decoder := json.NewDecoder(strings.NewReader(input))
+	start, err := decoder.Token()
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("nothing to recover: %v", err)
+	}
+
+	var closer string
+	switch start {
+	case json.Delim('['):
+		closer = "]"
+	case json.Delim('{'):
+		closer = "}"
+	default:
+		return input[:decoder.InputOffset()], decoder.InputOffset(), 1, nil
+	}
+
+	end := decoder.InputOffset()
+	kept := 0
+	for decoder.More() {
+		if closer == "}" {
+			if _, err := decoder.Token(); err != nil {
+				break
+			}
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			break
+		}
+		// A number running into the end of the input may have been cut short
+		if decoder.InputOffset() == int64(len(input)) && strings.IndexByte("-0123456789", value[0]) >= 0 {
+			break
+		}
+		end = decoder.InputOffset()
+		kept++
+	}
+	return input[:end] + closer, end, kept, nil
+}
+
+// bestEffortInput replaces invalid JSON with its recovered prefix for
+// --best-effort, warning about the cut. Valid input is returned as is, and
+// input with nothing to recover is left for the command to reject.
+func bestEffortInput(input string, stderr io.Writer) string {
+	if json.Valid([]byte(input)) {
+		return input
+	}
+	recovered, offset, kept, err := recoverPrefix(input)
+	if err != nil {
+		return input
+	}
+	fmt.Fprintf(stderr, "Warning: input is not valid JSON; kept %d complete value(s) before offset %d (--best-effort)\n", kept, offset)
+	return recovered
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRecoverPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		offset   int64
+		kept     int
+		wantErr  bool
+	}{
+		{name: "truncated array", input: `[1, {"a": 2}, "x", {"b": [1, 2`, expected: `[1, {"a": 2}, "x"]`, offset: 17, kept: 3},
+		{name: "array ending after a comma", input: `[1, 2,`, expected: `[1, 2]`, offset: 5, kept: 2},
+		{name: "number cut at the end", input: `[10, 20, 3`, expected: `[10, 20]`, offset: 7, kept: 2},
+		{name: "syntax error mid-array", input: `["a", "b", oops, "c"]`, expected: `["a", "b"]`, offset: 9, kept: 2},
+		{name: "truncated object", input: `{"a": 1, "b": [2], "c": tru`, expected: `{"a": 1, "b": [2]}`, offset: 17, kept: 2},
+		{name: "object cut inside a key", input: `{"a": 1, "b`, expected: `{"a": 1}`, offset: 7, kept: 1},
+		{name: "nothing complete", input: `[{"a": 1`, expected: `[]`, offset: 1, kept: 0},
+		{name: "scalar followed by junk", input: `"text" junk`, expected: `"text"`, offset: 6, kept: 1},
+		{name: "not JSON at all", input: `junk`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recovered, offset, kept, err := recoverPrefix(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("recoverPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if recovered != tt.expected || offset != tt.offset || kept != tt.kept {
+				t.Errorf("recoverPrefix() = %q, %d, %d, want %q, %d, %d", recovered, offset, kept, tt.expected, tt.offset, tt.kept)
+			}
+		})
+	}
+}
+
+func TestRunBestEffort(t *testing.T) {
+	input := `[{"id": 1}, {"id": 2}, {"id": 3, "na`
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"array", input}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() without --best-effort = %d, want 1", code)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--best-effort", "array", input}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if stdout.String() != `[{"id":1},{"id":2}]`+"\n" {
+		t.Errorf("run() stdout = %q, want the first two elements", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "kept 2 complete value(s) before offset 21") {
+		t.Errorf("run() stderr = %q, want the truncation point reported", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--best-effort", "array", `[1, 2]`}, nil, &stdout, &stderr); code != 0 || stderr.Len() != 0 {
+		t.Errorf("run() on valid input = %d, stderr %q, want 0 and no warning", code, stderr.String())
+	}
+}
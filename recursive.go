@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// vcsDirs are the version control directories the walk never enters
+var vcsDirs = map[string]bool{
+	".git": true,
+	".hg":  true,
+	".svn": true,
+	".bzr": true,
+}
+
+// findFiles walks the directory tree under root and returns the regular
+// files whose extension matches ext, ignoring case, in lexical order. The
+// leading dot of ext is optional. Version control directories such as
+// .git are skipped. With respectGitignore, so are files and directories
+// matched by .gitignore files in root and below.
+func findFiles(root, ext string, respectGitignore bool) ([]string, error) {
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	var ignore gitignore
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && vcsDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if respectGitignore {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if rel != "." && ignore.ignored(rel, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				if err := ignore.load(path, rel); err != nil {
+					return err
+				}
+			}
+		}
+		if d.Type().IsRegular() && strings.EqualFold(filepath.Ext(path), ext) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
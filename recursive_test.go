@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTree creates the named files, with their contents, under a new
+// temporary directory and returns it
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFindFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"b.json":            `{}`,
+		"a/one.json":        `{}`,
+		"a/deep/two.JSON":   `{}`,
+		"a/deep/notes.txt":  `x`,
+		"c/config.yaml":     `x: 1`,
+		"c/config.json.bak": `{}`,
+		".git/x.json":       `{}`,
+		"a/.hg/y.json":      `{}`,
+		".svn/z.json":       `{}`,
+	})
+
+	tests := []struct {
+		name     string
+		ext      string
+		expected []string
+	}{
+		{name: "json in every directory", ext: ".json", expected: []string{"a/deep/two.JSON", "a/one.json", "b.json"}},
+		{name: "extension without a dot", ext: "yaml", expected: []string{"c/config.yaml"}},
+		{name: "no matches", ext: ".toml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := findFiles(root, tt.ext, false)
+			if err != nil {
+				t.Fatalf("findFiles() error = %v", err)
+			}
+			var got []string
+			for _, file := range files {
+				rel, _ := filepath.Rel(root, file)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("findFiles() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := findFiles(filepath.Join(root, "missing"), ".json", false); err == nil {
+		t.Error("findFiles() expected error for a missing directory")
+	}
+}
+
+func TestRunRecursive(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.json":       `{"id": 1}`,
+		"sub/b.json":   `{"id": 2}`,
+		"sub/skip.txt": `not json`,
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-f", "--recursive", "encode", root}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	expected := `"{\"id\":1}"` + "\n" + `"{\"id\":2}"` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"-f", "--recursive", "--ext", ".txt", "encode", root}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("run() = %d, want 1 for an invalid file", code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("run() stdout = %q, want nothing when a file fails", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "stopped at "+filepath.Join(root, "sub", "skip.txt")) {
+		t.Errorf("run() stderr = %q, want the failing file named", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--recursive", "encode", root}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("run() without -f = %d, want 1", code)
+	}
+}
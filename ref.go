@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// refKey is the JSON Reference member replaced under --resolve-refs
+const refKey = "$ref"
+
+// resolveRefs replaces every {"$ref": "#/pointer"} object with the value
+// the pointer addresses in the same document, as in JSON Schema. Members
+// next to "$ref" are ignored, following the JSON Reference draft. Only
+// references local to the document are supported.
+func resolveRefs(doc interface{}) (interface{}, error) {
+	return expandRefs(doc, doc, nil)
+}
+
+// expandRefs resolves the references within value; active lists the
+// references currently being expanded, to detect cycles
+func expandRefs(value, doc interface{}, active []string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if target, ok := v[refKey]; ok {
+			ref, ok := target.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a string, got %s", refKey, jsonType(target))
+			}
+			return expandRef(ref, doc, active)
+		}
+		resolved := make(map[string]interface{}, len(v))
+		for _, key := range sortedKeys(v) {
+			child, err := expandRefs(v[key], doc, active)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = child
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, child := range v {
+			child, err := expandRefs(child, doc, active)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = child
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// expandRef looks up a single reference and resolves the references in
+// the value it points to
+func expandRef(ref string, doc interface{}, active []string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported %s %q: only references within the document (#/...) are resolved", refKey, ref)
+	}
+	for i, seen := range active {
+		if seen == ref {
+			cycle := append(append([]string{}, active[i:]...), ref)
+			return nil, fmt.Errorf("circular %s: %s", refKey, strings.Join(cycle, " -> "))
+		}
+	}
+
+	// The fragment is URI-encoded, e.g. "#/a%20b" for the key "a b"
+	pointer, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %v", refKey, ref, err)
+	}
+	target, err := resolvePointer(doc, pointer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %v", refKey, ref, err)
+	}
+	return expandRefs(target, doc, append(active, ref))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResolveRefs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "valid ref",
+			input:    `{"definitions": {"id": {"type": "integer"}}, "item": {"$ref": "#/definitions/id"}}`,
+			expected: `{"definitions":{"id":{"type":"integer"}},"item":{"type":"integer"}}`,
+		},
+		{
+			name:     "ref into array",
+			input:    `{"list": ["a", "b"], "second": {"$ref": "#/list/1"}}`,
+			expected: `{"list":["a","b"],"second":"b"}`,
+		},
+		{
+			name:     "chained refs",
+			input:    `{"a": {"$ref": "#/b"}, "b": [{"$ref": "#/c"}], "c": 1}`,
+			expected: `{"a":[1],"b":[1],"c":1}`,
+		},
+		{
+			name:     "escaped pointer and encoded fragment",
+			input:    `{"defs": {"a/b c": true}, "x": {"$ref": "#/defs/a~1b%20c"}}`,
+			expected: `{"defs":{"a/b c":true},"x":true}`,
+		},
+		{
+			name:     "sibling members ignored",
+			input:    `{"d": 1, "x": {"$ref": "#/d", "description": "dropped"}}`,
+			expected: `{"d":1,"x":1}`,
+		},
+		{
+			name:    "circular ref",
+			input:   `{"a": {"$ref": "#/b"}, "b": {"$ref": "#/a"}}`,
+			wantErr: "circular $ref",
+		},
+		{
+			name:    "ref to an ancestor",
+			input:   `{"node": {"child": {"$ref": "#/node"}}}`,
+			wantErr: "circular $ref",
+		},
+		{
+			name:    "missing target",
+			input:   `{"x": {"$ref": "#/nowhere"}}`,
+			wantErr: "not found",
+		},
+		{
+			name:    "remote ref",
+			input:   `{"x": {"$ref": "other.json#/a"}}`,
+			wantErr: "unsupported $ref",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			result, err := resolveRefs(data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveRefs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveRefs() error = %v", err)
+			}
+			output, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("resolveRefs() = %s, want %s", output, tt.expected)
+			}
+		})
+	}
+}
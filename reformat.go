@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// reformatJSON re-serializes JSON text in a consistent style:
+//
+//	compact  all insignificant whitespace removed, key order kept
+//	pretty   indented with two spaces, key order kept
+//	sorted   compact with object keys sorted
+func reformatJSON(jsonStr, style string) (string, error) {
+	var buf bytes.Buffer
+	switch style {
+	case "compact":
+		if err := json.Compact(&buf, []byte(jsonStr)); err != nil {
+			return "", err
+		}
+	case "pretty":
+		if err := json.Indent(&buf, []byte(jsonStr), "", "  "); err != nil {
+			return "", err
+		}
+	case "sorted":
+		// Decode numbers as json.Number so that re-encoding cannot lose precision
+		decoder := json.NewDecoder(bytes.NewReader([]byte(jsonStr)))
+		decoder.UseNumber()
+		var data interface{}
+		if err := decoder.Decode(&data); err != nil {
+			return "", err
+		}
+		sorted, err := json.Marshal(data)
+		if err != nil {
+			return "", err
+		}
+		buf.Write(sorted)
+	default:
+		return "", fmt.Errorf("unknown reformat style %q (want compact, pretty or sorted)", style)
+	}
+	return buf.String(), nil
+}
+
+// prettyJSON lays out JSON text over several lines, indented by
+// opts.indent per level. Key order, numbers and escapes are kept exactly as
+// written, as only whitespace changes.
+func prettyJSON(jsonStr string, opts prettyOptions) (string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(jsonStr)); err != nil {
+		return "", err
+	}
+	root, _ := parseJSONNode(compact.String())
+	var b strings.Builder
+	opts.write(&b, root, 0)
+	return b.String(), nil
+}
+
+// parseIndent reads an --indent value: a number of spaces, or a run of
+// spaces and tabs in which the two characters \t also stand for a tab,
+// since shells pass "\t" through unchanged
+func parseIndent(spec string) (string, error) {
+	if n, err := strconv.Atoi(spec); err == nil {
+		if n < 0 {
+			return "", fmt.Errorf("invalid --indent %d (want 0 or more spaces)", n)
+		}
+		return strings.Repeat(" ", n), nil
+	}
+	indent := strings.ReplaceAll(spec, `\t`, "\t")
+	if strings.Trim(indent, " \t") != "" {
+		return "", fmt.Errorf(`invalid --indent %q (want a number of spaces, or spaces and tabs such as "\t")`, spec)
+	}
+	return indent, nil
+}
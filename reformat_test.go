@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReformatJSON(t *testing.T) {
+	// The same document written with different spacing
+	inputs := []string{
+		`{"b":1,"a":[1,2],"n":12345678901234567890}`,
+		`{ "b" : 1,  "a" : [ 1, 2 ], "n" : 12345678901234567890 }`,
+		"{\n\t\"b\": 1,\n\t\"a\": [\n\t\t1,\n\t\t2\n\t],\n\t\"n\": 12345678901234567890\n}",
+	}
+
+	tests := []struct {
+		style    string
+		expected string
+	}{
+		{
+			style:    "compact",
+			expected: `{"b":1,"a":[1,2],"n":12345678901234567890}`,
+		},
+		{
+			style:    "pretty",
+			expected: "{\n  \"b\": 1,\n  \"a\": [\n    1,\n    2\n  ],\n  \"n\": 12345678901234567890\n}",
+		},
+		{
+			style:    "sorted",
+			expected: `{"a":[1,2],"b":1,"n":12345678901234567890}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			for _, input := range inputs {
+				result, err := reformatJSON(input, tt.style)
+				if err != nil {
+					t.Fatalf("reformatJSON() error = %v (input: %s)", err, input)
+				}
+				if result != tt.expected {
+					t.Errorf("reformatJSON() = %q, want %q (input: %s)", result, tt.expected, input)
+				}
+			}
+		})
+	}
+}
+
+func TestReformatDecodedJSON(t *testing.T) {
+	decoded, err := decodeJSON(`"{ \"key\" :   \"value\" }"`)
+	if err != nil {
+		t.Fatalf("decodeJSON() error = %v", err)
+	}
+	result, err := reformatJSON(decoded, "compact")
+	if err != nil {
+		t.Fatalf("reformatJSON() error = %v", err)
+	}
+	if result != `{"key":"value"}` {
+		t.Errorf("reformatJSON() = %v, want %v", result, `{"key":"value"}`)
+	}
+}
+
+func TestReformatJSONUnknownStyle(t *testing.T) {
+	if _, err := reformatJSON(`{}`, "fancy"); err == nil {
+		t.Errorf("reformatJSON() expected error for unknown style")
+	}
+}
+
+func TestParseIndent(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "two spaces", spec: "2", expected: "  "},
+		{name: "zero", spec: "0", expected: ""},
+		{name: "escaped tab", spec: `\t`, expected: "\t"},
+		{name: "literal tab", spec: "\t", expected: "\t"},
+		{name: "mixed", spec: ` \t `, expected: " \t "},
+		{name: "negative", spec: "-1", wantErr: true},
+		{name: "other characters", spec: "--", wantErr: true},
+		{name: "other escape", spec: `\n`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indent, err := parseIndent(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIndent(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if indent != tt.expected {
+				t.Errorf("parseIndent(%q) = %q, want %q", tt.spec, indent, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrettyJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		indent   string
+		expected string
+	}{
+		{
+			name:     "key order, numbers and escapes kept",
+			input:    `{"b":1.50,"a":["\u00e9\/",1e3],"n":12345678901234567890}`,
+			indent:   "  ",
+			expected: "{\n  \"b\": 1.50,\n  \"a\": [\n    \"\\u00e9\\/\",\n    1e3\n  ],\n  \"n\": 12345678901234567890\n}",
+		},
+		{
+			name:     "tabs",
+			input:    `{"a":{"b":[]}}`,
+			indent:   "\t",
+			expected: "{\n\t\"a\": {\n\t\t\"b\": []\n\t}\n}",
+		},
+		{
+			name:     "already indented differently",
+			input:    "[\n        1,\n  2 ]",
+			indent:   " ",
+			expected: "[\n 1,\n 2\n]",
+		},
+		{
+			name:     "scalar",
+			input:    `"text"`,
+			indent:   "  ",
+			expected: `"text"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := prettyJSON(tt.input, prettyOptions{indent: tt.indent})
+			if err != nil {
+				t.Fatalf("prettyJSON() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("prettyJSON() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunPretty(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{
+			name:   "default indent",
+			args:   []string{"pretty", `{"z":1,"a":[true]}`},
+			stdout: "{\n  \"z\": 1,\n  \"a\": [\n    true\n  ]\n}\n",
+		},
+		{
+			name:   "tab indent",
+			args:   []string{"pretty", "--indent", `\t`, `{"z":1}`},
+			stdout: "{\n\t\"z\": 1\n}\n",
+		},
+		{
+			name:     "invalid JSON",
+			args:     []string{"pretty", `{"z":}`},
+			exitCode: 1,
+		},
+		{
+			name:     "trailing data",
+			args:     []string{"pretty", `{} {}`},
+			exitCode: 1,
+		},
+		{
+			name:     "invalid indent",
+			args:     []string{"pretty", "--indent", "wide", `{}`},
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
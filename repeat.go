@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// repeatCommand runs command n times and reports the total and average
+// time on stderr. Only the first run writes to out and stderr, so the
+// result appears once; later runs write to io.Discard. It stops at the
+// first run that fails and returns its exit code, without a report.
+func repeatCommand(n int, out, stderr io.Writer, command func(out, stderr io.Writer) (int, error)) (int, error) {
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		runOut, runErr := io.Discard, io.Discard
+		if i == 0 {
+			runOut, runErr = out, stderr
+		}
+		if code, err := command(runOut, runErr); code != 0 || err != nil {
+			return code, err
+		}
+	}
+	elapsed := time.Since(start)
+	fmt.Fprintf(stderr, "repeat: %d runs in %v (%v per run)\n", n, elapsed, elapsed/time.Duration(n))
+	return 0, nil
+}
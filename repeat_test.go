@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRepeatCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		n          int
+		failAt     int // run that fails, counting from 1, or 0 for none
+		wantRuns   int
+		wantCode   int
+		wantStdout string
+	}{
+		{name: "single run", n: 1, wantRuns: 1, wantStdout: "result\n"},
+		{name: "many runs emit once", n: 25, wantRuns: 25, wantStdout: "result\n"},
+		{name: "first run fails", n: 5, failAt: 1, wantRuns: 1, wantCode: 1},
+		{name: "later run fails", n: 5, failAt: 3, wantRuns: 3, wantCode: 1, wantStdout: "result\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			runs := 0
+			code, err := repeatCommand(tt.n, &stdout, &stderr, func(out, stderr io.Writer) (int, error) {
+				runs++
+				if runs == tt.failAt {
+					fmt.Fprintln(stderr, "Error: failed")
+					return 1, nil
+				}
+				fmt.Fprintln(out, "result")
+				fmt.Fprintln(stderr, "Warning: noted")
+				return 0, nil
+			})
+			if err != nil {
+				t.Fatalf("repeatCommand() error = %v", err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("repeatCommand() = %d, want %d", code, tt.wantCode)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("repeatCommand() ran %d times, want %d", runs, tt.wantRuns)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("repeatCommand() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			report := fmt.Sprintf("repeat: %d runs in ", tt.n)
+			if got := strings.Contains(stderr.String(), report); got != (tt.wantCode == 0) {
+				t.Errorf("repeatCommand() stderr = %q, report expected %v", stderr.String(), tt.wantCode == 0)
+			}
+			if tt.failAt != 1 && strings.Count(stderr.String(), "Warning: noted") != 1 {
+				t.Errorf("repeatCommand() stderr = %q, want one warning", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRepeatCommandWriteError(t *testing.T) {
+	runs := 0
+	writeErr := errors.New("broken pipe")
+	_, err := repeatCommand(3, io.Discard, io.Discard, func(out, stderr io.Writer) (int, error) {
+		runs++
+		return 0, writeErr
+	})
+	if err != writeErr || runs != 1 {
+		t.Errorf("repeatCommand() = %v after %d runs, want %v after 1", err, runs, writeErr)
+	}
+}
+
+func TestRunRepeat(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--repeat", "10", "encode", `{"b": 1, "a": 2}`}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if expected := `"{\"a\":2,\"b\":1}"` + "\n"; stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+	if !strings.HasPrefix(stderr.String(), "repeat: 10 runs in ") {
+		t.Errorf("run() stderr = %q, want the timing report", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--repeat", "0", "encode", `{}`}, nil, io.Discard, &stderr); code != 1 {
+		t.Errorf("run() with --repeat 0 = %d, want 1", code)
+	}
+}
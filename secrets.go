@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// secretKeyWords are fragments of member names that usually hold secrets
+var secretKeyWords = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "api-key", "privatekey", "private_key", "credential"}
+
+// secretPatterns match well-known credential formats
+var secretPatterns = []struct {
+	pattern *regexp.Regexp
+	reason  string
+}{
+	{regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), "looks like an AWS access key ID"},
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`), "contains a private key"},
+}
+
+// minSecretEntropy is the Shannon entropy, in bits per character, above
+// which a long string without spaces is taken to be random key material
+const minSecretEntropy = 4.0
+
+// secretFinding is a string value that looks like a secret
+type secretFinding struct {
+	Path   string
+	Reason string
+}
+
+// scanSecrets looks for string values that are likely secrets: values of
+// members with names like "password", strings matching known key formats,
+// and long strings random enough to be key material. Each value is reported
+// once, for the first reason found.
+func scanSecrets(doc interface{}) []secretFinding {
+	var findings []secretFinding
+	walkJSON(doc, nil, func(path []string, value interface{}) error {
+		s, ok := value.(string)
+		if !ok || s == "" {
+			return nil
+		}
+		if reason := secretReason(path, s); reason != "" {
+			findings = append(findings, secretFinding{Path: formatPointer(path), Reason: reason})
+		}
+		return nil
+	})
+	return findings
+}
+
+func secretReason(path []string, s string) string {
+	if len(path) > 0 {
+		name := strings.ToLower(path[len(path)-1])
+		for _, word := range secretKeyWords {
+			if strings.Contains(name, word) {
+				return fmt.Sprintf("member name %q suggests a secret", path[len(path)-1])
+			}
+		}
+	}
+	for _, p := range secretPatterns {
+		if p.pattern.MatchString(s) {
+			return p.reason
+		}
+	}
+	if len(s) >= 20 && strings.IndexFunc(s, unicode.IsSpace) < 0 && shannonEntropy(s) >= minSecretEntropy {
+		return "high-entropy string"
+	}
+	return ""
+}
+
+// shannonEntropy returns the entropy of a string in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// reportSecrets writes a warning for each likely secret in a JSON document
+func reportSecrets(w io.Writer, jsonStr string) error {
+	data, err := parseJSON(jsonStr)
+	if err != nil {
+		return err
+	}
+	for _, f := range scanSecrets(data) {
+		fmt.Fprintf(w, "Warning: possible secret at %q: %s\n", f.Path, f.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSplitNameLength keeps file names written by split-by well under the
+// 255-byte limit of common filesystems, leaving room for the extension
+const maxSplitNameLength = 200
+
+// splitBy groups array elements as groupBy does and writes each group to
+// its own file in dir, named after the group and holding a JSON array. It
+// returns the paths written, in group name order. Two groups whose names
+// sanitize to the same file name are an error rather than one overwriting
+// the other.
+func splitBy(array []interface{}, keyPointer, missingKey, dir string) ([]string, error) {
+	groups, err := groupBy(array, keyPointer, missingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(groups))
+	for _, group := range sortedKeys(groups) {
+		name := splitFileName(group)
+		if other, exists := names[name]; exists {
+			return nil, fmt.Errorf("groups %q and %q would both be written to %s.json", other, group, name)
+		}
+		names[name] = group
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+	var paths []string
+	for _, group := range sortedKeys(groups) {
+		content, err := json.Marshal(groups[group])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal group %q: %v", group, err)
+		}
+		path := filepath.Join(dir, splitFileName(group)+".json")
+		if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write group %q: %v", group, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// splitFileName turns a group name into a safe file name: letters, digits,
+// '-', '_' and '.' are kept, anything else, including path separators,
+// becomes '_'. Names that are empty or only dots, which would address the
+// directory itself or its parent, are prefixed with '_'.
+func splitFileName(group string) string {
+	var b strings.Builder
+	for _, r := range group {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	name := b.String()
+	if len(name) > maxSplitNameLength {
+		name = name[:maxSplitNameLength]
+	}
+	if strings.Trim(name, ".") == "" {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "plain name", input: "red", expected: "red"},
+		{name: "safe punctuation kept", input: "v1.2_beta-3", expected: "v1.2_beta-3"},
+		{name: "path separators replaced", input: "../etc/passwd", expected: ".._etc_passwd"},
+		{name: "windows separators replaced", input: `a\b:c`, expected: "a_b_c"},
+		{name: "spaces and unicode replaced", input: "São Paulo", expected: "S_o_Paulo"},
+		{name: "empty name", input: "", expected: "_"},
+		{name: "dot", input: ".", expected: "_."},
+		{name: "dot dot", input: "..", expected: "_.."},
+		{name: "long name truncated", input: strings.Repeat("x", 300), expected: strings.Repeat("x", maxSplitNameLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitFileName(tt.input); got != tt.expected {
+				t.Errorf("splitFileName(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitBy(t *testing.T) {
+	data, err := parseJSON(`[
+		{"name": "ada", "team": "red"},
+		{"name": "bob", "team": "blue"},
+		{"name": "cy", "team": "red"},
+		{"name": "dee", "team": "../ops"},
+		{"name": "eve"}
+	]`)
+	if err != nil {
+		t.Fatalf("parseJSON() error = %v", err)
+	}
+	array, err := arrayAt(data, "")
+	if err != nil {
+		t.Fatalf("arrayAt() error = %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "out")
+	paths, err := splitBy(array, "/team", "_missing", dir)
+	if err != nil {
+		t.Fatalf("splitBy() error = %v", err)
+	}
+
+	expected := map[string]string{
+		".._ops.json":   `[{"name":"dee","team":"../ops"}]`,
+		"_missing.json": `[{"name":"eve"}]`,
+		"blue.json":     `[{"name":"bob","team":"blue"}]`,
+		"red.json":      `[{"name":"ada","team":"red"},{"name":"cy","team":"red"}]`,
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("splitBy() wrote %v, want %d files", paths, len(expected))
+	}
+	for _, path := range paths {
+		want, ok := expected[filepath.Base(path)]
+		if !ok || filepath.Dir(path) != dir {
+			t.Errorf("splitBy() wrote unexpected file %s", path)
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("os.ReadFile() error = %v", err)
+		}
+		if string(content) != want+"\n" {
+			t.Errorf("%s = %s, want %s", filepath.Base(path), content, want)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("os.ReadDir() error = %v", err)
+	}
+	if len(entries) != len(expected) {
+		t.Errorf("output directory has %d entries, want %d", len(entries), len(expected))
+	}
+}
+
+func TestSplitByCollision(t *testing.T) {
+	array := []interface{}{
+		map[string]interface{}{"team": "a/b"},
+		map[string]interface{}{"team": "a_b"},
+	}
+	dir := filepath.Join(t.TempDir(), "out")
+	if _, err := splitBy(array, "/team", "_missing", dir); err == nil {
+		t.Fatalf("splitBy() expected error for groups sharing a file name")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("splitBy() created %s despite the collision", dir)
+	}
+}
+
+func TestRunSplitBy(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	args := []string{"split-by", "--path", "/rows", "--key", "/n", "--out-dir", dir, `{"rows": [{"n": 1}, {"n": 2}, {"n": 1}]}`}
+	if code := run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	expected := filepath.Join(dir, "1.json") + "\n" + filepath.Join(dir, "2.json") + "\n"
+	if stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"split-by", "--key", "/n", `[]`}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("run() without --out-dir = %d, want 1", code)
+	}
+}
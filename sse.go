@@ -0,0 +1,26 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeSSE writes a value as one Server-Sent Events message: with withID
+// an "id:" line holding the element's index, then a "data:" line with the
+// minified JSON, which never contains a newline, and the blank line that
+// ends the event
+func writeSSE(out io.Writer, index int, value interface{}, withID bool) error {
+	output, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+	var b strings.Builder
+	if withID {
+		fmt.Fprintf(&b, "id: %d\n", index)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", output)
+	_, err = io.WriteString(out, b.String())
+	return err
+}
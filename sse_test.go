@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunSSE(t *testing.T) {
+	input := `{"events": [{"n": 1}, {"n": 2}, {"n": 3}]}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+		stderr   string
+	}{
+		{
+			name:   "data lines",
+			args:   []string{"--each", "/events", "--sse", "tondjson", input},
+			stdout: "data: {\"n\":1}\n\ndata: {\"n\":2}\n\ndata: {\"n\":3}\n\n",
+		},
+		{
+			name:   "id lines with the element index",
+			args:   []string{"--each", "/events", "--sse", "--sse-id", "--expr", "value.n != 2", "tondjson", input},
+			stdout: "id: 0\ndata: {\"n\":1}\n\nid: 2\ndata: {\"n\":3}\n\n",
+		},
+		{
+			name:   "project",
+			args:   []string{"--each", "/events", "--sse", "--fields", "/n", "project", input},
+			stdout: "data: {\"n\":1}\n\ndata: {\"n\":2}\n\ndata: {\"n\":3}\n\n",
+		},
+		{
+			name:     "without --each",
+			args:     []string{"--sse", "tondjson", `[1]`},
+			exitCode: 1,
+			stderr:   "--sse requires --each",
+		},
+		{
+			name:     "id without --sse",
+			args:     []string{"--each", "", "--sse-id", "tondjson", `[1]`},
+			exitCode: 1,
+			stderr:   "--sse-id requires --sse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("run() stderr = %q, want %q", stderr.String(), tt.stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// numericStats summarizes the numbers found in an array
+type numericStats struct {
+	Count   int     `json:"count"`
+	Skipped int     `json:"skipped"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Mean    float64 `json:"mean"`
+	Median  float64 `json:"median"`
+	P90     float64 `json:"p90"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+}
+
+// arrayStats computes statistics over the numbers at valuePointer within each
+// array element, or over the elements themselves when valuePointer is empty.
+// Elements where the pointer does not resolve to a number are counted as
+// skipped.
+func arrayStats(array []interface{}, valuePointer string) (numericStats, error) {
+	if _, err := parsePointer(valuePointer); err != nil {
+		return numericStats{}, err
+	}
+
+	var stats numericStats
+	var values []float64
+	for _, element := range array {
+		value, err := resolvePointer(element, valuePointer)
+		n, isNumber := value.(float64)
+		if err != nil || !isNumber {
+			stats.Skipped++
+			continue
+		}
+		values = append(values, n)
+	}
+	if len(values) == 0 {
+		return numericStats{}, fmt.Errorf("no numeric values at %q in %d elements", valuePointer, len(array))
+	}
+
+	sort.Float64s(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	stats.Count = len(values)
+	stats.Min = values[0]
+	stats.Max = values[len(values)-1]
+	stats.Mean = sum / float64(len(values))
+	stats.Median = percentile(values, 50)
+	stats.P90 = percentile(values, 90)
+	stats.P95 = percentile(values, 95)
+	stats.P99 = percentile(values, 99)
+	return stats, nil
+}
+
+// percentile returns the p-th percentile of sorted values, interpolating
+// linearly between the two nearest ranks
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1) / 100
+	lower := int(math.Floor(rank))
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
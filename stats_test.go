@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestArrayStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		key      string
+		expected numericStats
+		wantErr  bool
+	}{
+		{
+			name:     "numeric field of objects",
+			input:    `[{"age": 30}, {"age": 10}, {"age": 20}, {"age": 40}, {"age": 50}]`,
+			key:      "/age",
+			expected: numericStats{Count: 5, Min: 10, Max: 50, Mean: 30, Median: 30, P90: 46, P95: 48, P99: 49.6},
+		},
+		{
+			name:     "non-numeric and missing values skipped",
+			input:    `[{"age": 7}, {"age": "old"}, {"name": "x"}, {"age": null}, {"age": 3}, 5]`,
+			key:      "/age",
+			expected: numericStats{Count: 2, Skipped: 4, Min: 3, Max: 7, Mean: 5, Median: 5, P90: 6.6, P95: 6.8, P99: 6.96},
+		},
+		{
+			name:     "array of numbers",
+			input:    `[4, 1, 3, 2]`,
+			expected: numericStats{Count: 4, Min: 1, Max: 4, Mean: 2.5, Median: 2.5, P90: 3.7, P95: 3.85, P99: 3.97},
+		},
+		{
+			name:     "single value",
+			input:    `[{"v": -2.5}]`,
+			key:      "/v",
+			expected: numericStats{Count: 1, Min: -2.5, Max: -2.5, Mean: -2.5, Median: -2.5, P90: -2.5, P95: -2.5, P99: -2.5},
+		},
+		{name: "no numbers", input: `[{"v": "a"}]`, key: "/v", wantErr: true},
+		{name: "invalid key", input: `[1]`, key: "v", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			stats, err := arrayStats(data.([]interface{}), tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("arrayStats() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !sameStats(stats, tt.expected) {
+				t.Errorf("arrayStats() = %+v, want %+v", stats, tt.expected)
+			}
+		})
+	}
+}
+
+// sameStats compares statistics allowing for floating-point rounding
+func sameStats(a, b numericStats) bool {
+	near := func(x, y float64) bool {
+		d := x - y
+		return d < 1e-9 && d > -1e-9
+	}
+	return a.Count == b.Count && a.Skipped == b.Skipped &&
+		near(a.Min, b.Min) && near(a.Max, b.Max) && near(a.Mean, b.Mean) && near(a.Median, b.Median) &&
+		near(a.P90, b.P90) && near(a.P95, b.P95) && near(a.P99, b.P99)
+}
+
+func TestRunStats(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"stats", "--path", "/n", `[{"n": 1}, {"n": 2}, {"n": "x"}, {"n": 3}]`}
+	if code := run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	expected := `{"count":3,"skipped":1,"min":1,"max":3,"mean":2,"median":2,"p90":2.8,"p95":2.9,"p99":2.98}` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), expected)
+	}
+}
+
+func TestRunStatsRejectsKey(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"stats", "--key", "/n", `[{"n": 1}]`}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("run() = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "as --path, not --key") {
+		t.Errorf("run() stderr = %q, want it to point to --path", stderr.String())
+	}
+}
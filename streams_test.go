@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRunStreamSeparation checks the output contract: results go to stdout
+// and every diagnostic to stderr, so stdout stays clean JSON even when
+// warnings or reports are written
+func TestRunStreamSeparation(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		exitCode   int
+		wantStderr string
+	}{
+		{
+			name:       "duplicate key report",
+			args:       []string{"--report-dup-keys", "encode", `{"a": 1, "a": 2}`},
+			wantStderr: "duplicate keys at",
+		},
+		{
+			name:       "escape report",
+			args:       []string{"--report-escapes", "encode", `{"a": "x\ny"}`},
+			wantStderr: "quotes:",
+		},
+		{
+			name:       "duplicate keys in decoded output",
+			args:       []string{"--report-dup-keys", "decode", `"{\"a\": 1, \"a\": 2}"`},
+			wantStderr: "duplicate keys at",
+		},
+		{
+			name:       "invalid input",
+			args:       []string{"--preview-bytes", "4", "encode", `<html>`},
+			exitCode:   1,
+			wantStderr: "Error encoding JSON",
+		},
+		{
+			name:       "failed assertion",
+			args:       []string{"--assert-nonempty", "array", `[]`},
+			exitCode:   exitAssertionFailed,
+			wantStderr: "Assertion failed",
+		},
+		{
+			name:       "unknown command prints usage",
+			args:       []string{"reverse", `{}`},
+			exitCode:   1,
+			wantStderr: "Usage:",
+		},
+		{
+			name:       "unknown flag",
+			args:       []string{"--no-such-flag", "encode", `{}`},
+			exitCode:   2,
+			wantStderr: "flag provided but not defined",
+		},
+		{
+			name:       "help",
+			args:       []string{"-h"},
+			wantStderr: "Usage:",
+		},
+		{
+			name:       "parse timing",
+			args:       []string{"--parse-only", "--measure", "encode", `[1]`},
+			wantStderr: "parse time:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+			// Output, if any, must be a single JSON value per line
+			for _, line := range strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n") {
+				if line != "" && !json.Valid([]byte(line)) {
+					t.Errorf("run() stdout line %q is not JSON", line)
+				}
+			}
+			if tt.exitCode != 0 && stdout.Len() > 0 {
+				t.Errorf("run() stdout = %q, want nothing on failure", stdout.String())
+			}
+		})
+	}
+}
+
+// TestNoDirectConsoleOutput enforces that only main() touches the process
+// streams; everything else writes to the stdout and stderr handed to run()
+func TestNoDirectConsoleOutput(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatalf("ParseFile(%s) error = %v", file, err)
+		}
+		for _, decl := range parsed.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == "main" && fn.Recv == nil {
+				continue
+			}
+			ast.Inspect(decl, func(node ast.Node) bool {
+				sel, ok := node.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				pkg, ok := sel.X.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				name := pkg.Name + "." + sel.Sel.Name
+				switch name {
+				case "fmt.Print", "fmt.Printf", "fmt.Println", "os.Stdin", "os.Stdout", "os.Stderr":
+					t.Errorf("%s: %s used outside main()", fset.Position(sel.Pos()), name)
+				}
+				return true
+			})
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// checkStrictNumbers reports the first number in a document that cannot be
+// held in a float64 without changing its value, such as a 20-digit integer.
+// Numbers that merely have a different spelling once converted, like 1.0
+// or 1e2, are accepted.
+func checkStrictNumbers(jsonStr string) error {
+	data, err := parseJSONNumbers(jsonStr)
+	if err != nil {
+		return err
+	}
+	return walkJSON(data, nil, func(path []string, value interface{}) error {
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil
+		}
+		f, err := strconv.ParseFloat(string(number), 64)
+		if err != nil || !sameNumber(number, json.Number(strconv.FormatFloat(f, 'g', -1, 64))) {
+			return fmt.Errorf("number %s at %q cannot be represented exactly as a float64 (--strict-numbers)", number, formatPointer(path))
+		}
+		return nil
+	})
+}
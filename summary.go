@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// batchSummary tallies the inputs of a --recursive or --each run for
+// --summary: the files of the walk, or the elements of the streamed array
+type batchSummary struct {
+	start     time.Time
+	processed int
+	failed    int
+	// skipped counts inputs that were not processed: files after the one
+	// that failed, or elements dropped by --sample-rate or --expr
+	skipped  int
+	bytesIn  int64
+	bytesOut int64
+}
+
+// write reports the tallies on one line, such as
+// "summary: 3 processed, 2 succeeded, 1 failed, 0 skipped, ..."
+func (s *batchSummary) write(w io.Writer) {
+	fmt.Fprintf(w, "summary: %d processed, %d succeeded, %d failed, %d skipped, %d bytes in, %d bytes out in %v\n",
+		s.processed, s.processed-s.failed, s.failed, s.skipped, s.bytesIn, s.bytesOut, time.Since(s.start))
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
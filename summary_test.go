@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSummary(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.json": `{"a": 1}`,
+		"b.json": `{"b": }`,
+		"c.json": `{"c": 3}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+		summary  string
+	}{
+		{
+			name:     "recursive stops at a failing file",
+			args:     []string{"-f", "--recursive", "--summary", "encode", dir},
+			exitCode: 1,
+			summary:  "summary: 2 processed, 1 succeeded, 1 failed, 1 skipped, 15 bytes in, 0 bytes out in ",
+		},
+		{
+			name:    "each with elements dropped by --expr",
+			args:    []string{"--each", "", "--expr", "value > 1", "--summary", "tondjson", `[1, 2, 3]`},
+			stdout:  "2\n3\n",
+			summary: "summary: 2 processed, 2 succeeded, 0 failed, 1 skipped, 9 bytes in, 4 bytes out in ",
+		},
+		{
+			name:     "each failing at an element",
+			args:     []string{"--each", "", "--summary", "tondjson", `[1, 2, nope]`},
+			exitCode: 1,
+			stdout:   "1\n2\n",
+			summary:  "summary: 3 processed, 2 succeeded, 1 failed, 0 skipped, ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+			if !strings.Contains(stderr.String(), tt.summary) {
+				t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), tt.summary)
+			}
+		})
+	}
+}
+
+func TestRunSummaryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "single input", args: []string{"--summary", "encode", `{}`}, wantErr: "--summary requires --recursive or --each"},
+		{name: "with repeat", args: []string{"--summary", "--repeat", "2", "--each", "", "tondjson", `[1]`}, wantErr: "--summary cannot be combined with --repeat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != 1 {
+				t.Errorf("run() = %d, want 1", code)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("run() stderr = %q, want %q", stderr.String(), tt.wantErr)
+			}
+		})
+	}
+}
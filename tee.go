@@ -0,0 +1,152 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checksumAlgorithms are the digests --checksum can write, by name
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// parseChecksum validates a --checksum algorithm name
+func parseChecksum(name string) (string, error) {
+	name = strings.ToLower(name)
+	if _, ok := checksumAlgorithms[name]; !ok {
+		names := make([]string, 0, len(checksumAlgorithms))
+		for n := range checksumAlgorithms {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("unknown checksum algorithm %q (want %s)", name, strings.Join(names, " or "))
+	}
+	return name, nil
+}
+
+// openTee creates the named file and returns a writer that copies everything
+// written to stdout into it as well. Stdout is written first, and once a
+// write to the file fails the file is left alone while stdout keeps getting
+// the output; closing the returned closer reports the failure. With compress the file is gzipped and ".gz" is added to its name unless
+// it already ends in it; closing the returned closer finishes the stream.
+// With bom the file content starts with a UTF-8 byte order mark. With a
+// checksum algorithm, closing also writes the digest of the file to a
+// sidecar named after it, such as out.json.sha256.
+func openTee(stdout io.Writer, filename string, compress, bom bool, checksum string) (io.Writer, io.Closer, error) {
+	if compress && !strings.HasSuffix(filename, ".gz") {
+		filename += ".gz"
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	tee := &teeFile{Writer: file, file: file}
+	if checksum != "" {
+		tee.checksum = checksum
+		tee.hash = checksumAlgorithms[checksum]()
+		tee.Writer = io.MultiWriter(file, tee.hash)
+	}
+	if compress {
+		tee.gzip = gzip.NewWriter(tee.Writer)
+		tee.Writer = tee.gzip
+	}
+	if bom {
+		if tee.write(bomUTF8); tee.err != nil {
+			err := tee.err
+			tee.Close()
+			return nil, nil, err
+		}
+	}
+	return &teeWriter{stdout: stdout, file: tee}, tee, nil
+}
+
+// teeWriter writes to stdout and then to the --tee file. A failing file
+// never fails the write, so output keeps reaching stdout.
+type teeWriter struct {
+	stdout io.Writer
+	file   *teeFile
+}
+
+func (w *teeWriter) Write(p []byte) (int, error) {
+	n, err := w.stdout.Write(p)
+	if err != nil {
+		return n, err
+	}
+	w.file.write(p)
+	return n, nil
+}
+
+// teeFile is the file written by --tee, through a gzip stream with --gzip.
+// Closing it finishes the stream, closes the file and then writes the
+// checksum sidecar, if any.
+type teeFile struct {
+	io.Writer
+	gzip     *gzip.Writer
+	file     *os.File
+	hash     hash.Hash
+	checksum string
+	// err is the first write error; later writes are skipped
+	err error
+}
+
+func (t *teeFile) write(p []byte) {
+	if t.err == nil {
+		_, t.err = t.Writer.Write(p)
+	}
+}
+
+// Close returns the first write error, if any, and otherwise any error
+// from finishing the file. The checksum of a file that failed is not
+// written.
+func (t *teeFile) Close() error {
+	err := t.err
+	if t.gzip != nil {
+		if closeErr := t.gzip.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if closeErr := t.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && t.hash != nil {
+		err = writeChecksum(t.file.Name(), t.checksum, t.hash.Sum(nil))
+	}
+	return err
+}
+
+// writeChecksum writes a digest of the named file to <name>.<algorithm> in
+// the format of sha256sum and sha512sum, so it can be checked with -c
+func writeChecksum(name, algorithm string, sum []byte) error {
+	line := fmt.Sprintf("%x  %s\n", sum, filepath.Base(name))
+	if err := os.WriteFile(name+"."+algorithm, []byte(line), 0644); err != nil {
+		return fmt.Errorf("writing checksum: %v", err)
+	}
+	return nil
+}
+
+// bomWriter starts stdout with a UTF-8 byte order mark for --emit-bom-stdout.
+// The mark is written with the first output, so a run that fails before
+// writing anything still leaves stdout empty.
+type bomWriter struct {
+	io.Writer
+	written bool
+}
+
+func (w *bomWriter) Write(p []byte) (int, error) {
+	if !w.written && len(p) > 0 {
+		if _, err := w.Writer.Write(bomUTF8); err != nil {
+			return 0, err
+		}
+		w.written = true
+	}
+	return w.Writer.Write(p)
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenTee(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out.json")
+
+	var stdout bytes.Buffer
+	out, file, err := openTee(&stdout, filename, false, false, "")
+	if err != nil {
+		t.Fatalf("openTee() error = %v", err)
+	}
+
+	if err := writeOutput(out, `"{\"key\":\"value\"}"`); err != nil {
+		t.Fatalf("writeOutput() error = %v", err)
+	}
+	if err := printJSON(out, []interface{}{"a", 1.0}); err != nil {
+		t.Fatalf("printJSON() error = %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read tee file: %v", err)
+	}
+
+	expected := "\"{\\\"key\\\":\\\"value\\\"}\"\n[\"a\",1]\n"
+	if stdout.String() != expected {
+		t.Errorf("stdout = %q, want %q", stdout.String(), expected)
+	}
+	if string(content) != stdout.String() {
+		t.Errorf("tee file = %q, want %q", content, stdout.String())
+	}
+}
+
+func TestOpenTeeFileErrorKeepsStdout(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out.json")
+
+	var stdout bytes.Buffer
+	out, file, err := openTee(&stdout, filename, false, false, "")
+	if err != nil {
+		t.Fatalf("openTee() error = %v", err)
+	}
+	// Closing the file early makes every write to it fail
+	file.(*teeFile).file.Close()
+
+	for _, line := range []string{"first\n", "second\n"} {
+		if _, err := io.WriteString(out, line); err != nil {
+			t.Errorf("Write() error = %v, want stdout to keep going", err)
+		}
+	}
+	if stdout.String() != "first\nsecond\n" {
+		t.Errorf("stdout = %q, want %q", stdout.String(), "first\nsecond\n")
+	}
+	if err := file.Close(); err == nil {
+		t.Errorf("Close() expected the write error")
+	}
+}
+
+func TestOpenTeeInvalidPath(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing", "out.json")
+	if _, _, err := openTee(&bytes.Buffer{}, filename, false, false, ""); err == nil {
+		t.Errorf("openTee() expected error for a missing directory")
+	}
+}
+
+func TestOpenTeeGzip(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{name: "suffix added", filename: "out.json", expected: "out.json.gz"},
+		{name: "suffix kept", filename: "out.json.gz", expected: "out.json.gz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var stdout bytes.Buffer
+			out, file, err := openTee(&stdout, filepath.Join(dir, tt.filename), true, false, "")
+			if err != nil {
+				t.Fatalf("openTee() error = %v", err)
+			}
+			if err := printJSON(out, map[string]interface{}{"key": "value"}); err != nil {
+				t.Fatalf("printJSON() error = %v", err)
+			}
+			if err := file.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			f, err := os.Open(filepath.Join(dir, tt.expected))
+			if err != nil {
+				t.Fatalf("Failed to open tee file: %v", err)
+			}
+			defer f.Close()
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("gzip.NewReader() error = %v", err)
+			}
+			content, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if stdout.String() != "{\"key\":\"value\"}\n" || string(content) != stdout.String() {
+				t.Errorf("stdout = %q, decompressed tee file = %q, want both %q", stdout.String(), content, "{\"key\":\"value\"}\n")
+			}
+		})
+	}
+}
+
+func TestOpenTeeBOM(t *testing.T) {
+	for _, bom := range []bool{false, true} {
+		filename := filepath.Join(t.TempDir(), "out.json")
+		var stdout bytes.Buffer
+		out, file, err := openTee(&stdout, filename, false, bom, "")
+		if err != nil {
+			t.Fatalf("openTee() error = %v", err)
+		}
+		if err := writeOutput(out, `{"a":1}`); err != nil {
+			t.Fatalf("writeOutput() error = %v", err)
+		}
+		file.Close()
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("Failed to read tee file: %v", err)
+		}
+		if stdout.String() != "{\"a\":1}\n" {
+			t.Errorf("bom=%v: stdout = %q, want no BOM", bom, stdout.String())
+		}
+		if bytes.HasPrefix(content, bomUTF8) != bom || !bytes.HasSuffix(content, stdout.Bytes()) {
+			t.Errorf("bom=%v: tee file = %q", bom, content)
+		}
+	}
+}
+
+func TestRunEmitBOMStdout(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{name: "without the flag", args: []string{"array", "[1]"}, stdout: "[1]\n"},
+		{name: "with the flag", args: []string{"--emit-bom-stdout", "array", "[1]"}, stdout: "\xef\xbb\xbf[1]\n"},
+		{name: "once for every --each result", args: []string{"--emit-bom-stdout", "--each", "", "tondjson", "[1, 2]"}, stdout: "\xef\xbb\xbf1\n2\n"},
+		{name: "nothing on failure", args: []string{"--emit-bom-stdout", "array", "[1"}, exitCode: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
+
+func TestRunEmitBOMStdoutWithTee(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out.json")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--emit-bom-stdout", "--tee", filename, "array", "[1]"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read tee file: %v", err)
+	}
+	if !bytes.HasPrefix(stdout.Bytes(), bomUTF8) {
+		t.Errorf("run() stdout = %q, want a BOM", stdout.String())
+	}
+	if string(content) != "[1]\n" {
+		t.Errorf("tee file = %q, want no BOM without --emit-bom", content)
+	}
+}
+
+func TestRunGzipRequiresTee(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--gzip", "array", "[1]"}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("run() = %d, want 1", code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("run() stdout = %q, want nothing", stdout.String())
+	}
+}
+
+func TestOpenTeeGzipCloseError(t *testing.T) {
+	var stdout bytes.Buffer
+	out, closer, err := openTee(&stdout, filepath.Join(t.TempDir(), "out.json"), true, false, "")
+	if err != nil {
+		t.Fatalf("openTee() error = %v", err)
+	}
+	if _, err := io.WriteString(out, "{\"key\":\"value\"}\n"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// The compressed data is only written when the stream is closed
+	closer.(*teeFile).file.Close()
+	if err := closer.Close(); err == nil {
+		t.Errorf("Close() expected error when the gzip stream cannot be flushed")
+	}
+}
+
+func TestRunTeeWriteError(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full not available")
+	}
+	filename := filepath.Join(t.TempDir(), "out.json.gz")
+	if err := os.Symlink("/dev/full", filename); err != nil {
+		t.Skipf("os.Symlink() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+	}{
+		{name: "plain", args: []string{"--tee", filename, "array", "[1]"}, stdout: "[1]\n"},
+		{name: "gzip", args: []string{"--tee", filename, "--gzip", "array", "[1]"}, stdout: "[1]\n"},
+		{name: "several lines", args: []string{"--tee", filename, "--each", "", "--line-buffered", "tondjson", "[1, 2, 3]"}, stdout: "1\n2\n3\n"},
+		{name: "fail on warnings", args: []string{"--tee", filename, "--fail-on-warnings", "array", "[1]"}, exitCode: exitWarnings, stdout: "[1]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.stdout)
+			}
+			if !strings.HasPrefix(stderr.String(), "Warning: writing the --tee file: ") {
+				t.Errorf("run() stderr = %q, want the write error as a warning", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunTeeOpenError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing", "out.json")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--tee", filename, "tondjson", "[1, 2]"}, nil, &stdout, &stderr); code != 0 {
+		t.Errorf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if stdout.String() != "1\n2\n" {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), "1\n2\n")
+	}
+	if !strings.HasPrefix(stderr.String(), "Warning: not writing the --tee file: ") {
+		t.Errorf("run() stderr = %q, want the open error as a warning", stderr.String())
+	}
+}
+
+func TestRunTeeChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		file     string
+		sidecar  string
+		checksum func([]byte) []byte
+	}{
+		{
+			name:    "sha256",
+			args:    []string{"--checksum", "sha256"},
+			file:    "out.json",
+			sidecar: "out.json.sha256",
+			checksum: func(b []byte) []byte {
+				sum := sha256.Sum256(b)
+				return sum[:]
+			},
+		},
+		{
+			name:    "sha512 with upper case name",
+			args:    []string{"--checksum", "SHA512"},
+			file:    "out.json",
+			sidecar: "out.json.sha512",
+			checksum: func(b []byte) []byte {
+				sum := sha512.Sum512(b)
+				return sum[:]
+			},
+		},
+		{
+			name:    "sha256 of the gzipped file",
+			args:    []string{"--checksum", "sha256", "--gzip", "--emit-bom"},
+			file:    "out.json.gz",
+			sidecar: "out.json.gz.sha256",
+			checksum: func(b []byte) []byte {
+				sum := sha256.Sum256(b)
+				return sum[:]
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			args := append(tt.args, "--tee", filepath.Join(dir, "out.json"), "encode", `{"key": "value"}`)
+			var stdout, stderr bytes.Buffer
+			if code := run(args, nil, &stdout, &stderr); code != 0 {
+				t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatalf("Failed to read tee file: %v", err)
+			}
+			sidecar, err := os.ReadFile(filepath.Join(dir, tt.sidecar))
+			if err != nil {
+				t.Fatalf("Failed to read checksum file: %v", err)
+			}
+			expected := hex.EncodeToString(tt.checksum(content)) + "  " + tt.file + "\n"
+			if string(sidecar) != expected {
+				t.Errorf("checksum file = %q, want %q", sidecar, expected)
+			}
+		})
+	}
+}
+
+func TestRunTeeChecksumErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"--checksum", "sha256", "encode", `{}`},
+		{"--checksum", "md5", "--tee", filepath.Join(dir, "out.json"), "encode", `{}`},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, nil, &stdout, &stderr); code != 1 {
+			t.Errorf("run(%q) = %d, want 1", args, code)
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("run(%q) stdout = %q, want nothing", args, stdout.String())
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("run() with an unknown algorithm created %d file(s)", len(entries))
+	}
+}
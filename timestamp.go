@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timestampLayouts maps the named --normalize-timestamps layouts to Go time
+// layouts
+var timestampLayouts = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123Z,
+	"date":        time.DateOnly,
+}
+
+// timestampInputLayouts are the formats recognized as timestamps. Times
+// without a zone are taken to be UTC.
+var timestampInputLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.ANSIC,
+	time.UnixDate,
+	time.DateOnly,
+}
+
+// parseTimestampLayout resolves a layout name, or accepts a Go reference
+// time layout such as "2006-01-02 15:04"
+func parseTimestampLayout(name string) (string, error) {
+	if layout, ok := timestampLayouts[strings.ToLower(name)]; ok {
+		return layout, nil
+	}
+	if strings.Contains(name, "2006") {
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown timestamp layout %q (want rfc3339, rfc3339nano, rfc1123, date or a Go layout)", name)
+}
+
+// parseTimestamp parses a string in any of the recognized timestamp formats.
+// A zone abbreviation whose offset is not known, such as EST outside a US
+// local time zone, is rejected rather than read as UTC.
+func parseTimestamp(s string) (time.Time, bool) {
+	for _, layout := range timestampInputLayouts {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		if strings.Contains(layout, "MST") && !knownZone(t) {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// knownZone reports whether the zone abbreviation t was parsed with has a
+// real offset. time.Parse gives unknown abbreviations a zero offset, and
+// takes known ones from the local time zone.
+func knownZone(t time.Time) bool {
+	name, offset := t.Zone()
+	if t.Location() == time.Local {
+		return true
+	}
+	return offset == 0 && (name == "UTC" || name == "GMT")
+}
+
+// normalizeTimestamps rewrites string values that parse as timestamps in
+// layout, converted to UTC. When keys is non-empty only the values of object
+// members with one of those names, including strings in arrays below them,
+// are considered.
+func normalizeTimestamps(value interface{}, layout string, keys map[string]bool) interface{} {
+	return normalizeTimestampValue(value, layout, keys, len(keys) == 0)
+}
+
+func normalizeTimestampValue(value interface{}, layout string, keys map[string]bool, eligible bool) interface{} {
+	switch v := value.(type) {
+	case string:
+		if !eligible {
+			return v
+		}
+		if t, ok := parseTimestamp(v); ok {
+			return t.UTC().Format(layout)
+		}
+		return v
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			normalized[key] = normalizeTimestampValue(child, layout, keys, len(keys) == 0 || keys[key])
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, child := range v {
+			normalized[i] = normalizeTimestampValue(child, layout, keys, eligible)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
+
+// parseKeyList splits a comma-separated list of key names into a set
+func parseKeyList(list string) map[string]bool {
+	keys := make(map[string]bool)
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
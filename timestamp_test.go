@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNormalizeTimestamps(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		layout   string
+		keys     string
+		expected string
+	}{
+		{
+			name:     "mixed formats to RFC 3339 UTC",
+			input:    `["2024-03-01T10:00:00+02:00", "2024-03-01T08:00:00.5Z", "2024-03-01 08:00:00", "Fri, 01 Mar 2024 09:00:00 +0100", "Fri, 01 Mar 2024 08:00:00 GMT", "Fri Mar  1 08:00:00 2024", "2024-03-01"]`,
+			layout:   "rfc3339",
+			expected: `["2024-03-01T08:00:00Z","2024-03-01T08:00:00Z","2024-03-01T08:00:00Z","2024-03-01T08:00:00Z","2024-03-01T08:00:00Z","2024-03-01T08:00:00Z","2024-03-01T00:00:00Z"]`,
+		},
+		{
+			name:     "fractional seconds kept with rfc3339nano",
+			input:    `"2024-03-01T10:00:00.25+02:00"`,
+			layout:   "rfc3339nano",
+			expected: `"2024-03-01T08:00:00.25Z"`,
+		},
+		{
+			name:     "go layout",
+			input:    `{"at": "2024-03-01T23:30:00-01:00"}`,
+			layout:   "2006-01-02 15:04",
+			expected: `{"at":"2024-03-02 00:30"}`,
+		},
+		{
+			name:     "date layout",
+			input:    `"Fri, 01 Mar 2024 23:00:00 -0200"`,
+			layout:   "date",
+			expected: `"2024-03-02"`,
+		},
+		{
+			name:     "unknown zone abbreviation unchanged",
+			input:    `["Tue, 02 Jan 2024 10:00:00 XYZ", "Tue Jan  2 10:00:00 XYZ 2024", "Tue, 02 Jan 2024 10:00:00 UTC"]`,
+			layout:   "rfc3339",
+			expected: `["Tue, 02 Jan 2024 10:00:00 XYZ","Tue Jan  2 10:00:00 XYZ 2024","2024-01-02T10:00:00Z"]`,
+		},
+		{
+			name:     "non-timestamps unchanged",
+			input:    `{"name": "2024", "note": "March 1st", "n": 20240301}`,
+			layout:   "rfc3339",
+			expected: `{"n":20240301,"name":"2024","note":"March 1st"}`,
+		},
+		{
+			name:     "limited to keys",
+			input:    `{"at": "2024-03-01", "seen": ["2024-03-02"], "version": "2024-01-01", "nested": {"at": "2024-03-03", "id": "2024-03-04"}}`,
+			layout:   "rfc3339",
+			keys:     "at, seen",
+			expected: `{"at":"2024-03-01T00:00:00Z","nested":{"at":"2024-03-03T00:00:00Z","id":"2024-03-04"},"seen":["2024-03-02T00:00:00Z"],"version":"2024-01-01"}`,
+		},
+		{
+			name:     "root string ignored with keys",
+			input:    `"2024-03-01"`,
+			layout:   "rfc3339",
+			keys:     "at",
+			expected: `"2024-03-01"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input interface{}
+			if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			layout, err := parseTimestampLayout(tt.layout)
+			if err != nil {
+				t.Fatalf("parseTimestampLayout() error = %v", err)
+			}
+			result, err := json.Marshal(normalizeTimestamps(input, layout, parseKeyList(tt.keys)))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("normalizeTimestamps() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTimestampLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "named", input: "RFC3339", expected: "2006-01-02T15:04:05Z07:00"},
+		{name: "go layout", input: "2006/01/02", expected: "2006/01/02"},
+		{name: "unknown", input: "iso", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimestampLayout(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimestampLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("parseTimestampLayout() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// fromTOML parses a TOML document into the equivalent JSON value. TOML
+// datetimes have no JSON counterpart and become RFC 3339 strings; local
+// dates, times and datetimes keep their partial RFC 3339 form. The floats
+// nan and inf have no JSON counterpart either and are rejected with their
+// path.
+func fromTOML(tomlStr string) (interface{}, error) {
+	var data map[string]interface{}
+	if _, err := toml.Decode(tomlStr, &data); err != nil {
+		return nil, fmt.Errorf("invalid TOML input: %v", err)
+	}
+	return tomlToJSON(data, nil)
+}
+
+func tomlToJSON(value interface{}, path []string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for _, key := range sortedKeys(v) {
+			c, err := tomlToJSON(v[key], append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			converted[key] = c
+		}
+		return converted, nil
+	case []map[string]interface{}:
+		// Arrays of tables
+		converted := make([]interface{}, len(v))
+		for i, child := range v {
+			c, err := tomlToJSON(child, append(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = c
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, child := range v {
+			c, err := tomlToJSON(child, append(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = c
+		}
+		return converted, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("JSON cannot represent the float %s at %q", tomlFloatName(v), formatPointer(path))
+		}
+		return v, nil
+	case time.Time:
+		// The TOML decoder marks local values with dedicated zone names
+		switch v.Location().String() {
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999"), nil
+		case "date-local":
+			return v.Format("2006-01-02"), nil
+		case "time-local":
+			return v.Format("15:04:05.999999999"), nil
+		}
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		return value, nil
+	}
+}
+
+// tomlFloatName spells a non-finite float the way TOML writes it
+func tomlFloatName(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case f > 0:
+		return "inf"
+	}
+	return "-inf"
+}
+
+// toTOML serializes a JSON document as TOML. The root must be an object, and
+// null has no TOML representation so it is rejected with its path.
+func toTOML(jsonStr string, limits limitOptions) (string, error) {
+	data, err := parseJSONNumbers(jsonStr)
+	if err != nil {
+		return "", err
+	}
+	if err := checkLimits(data, limits); err != nil {
+		return "", err
+	}
+	if _, ok := data.(map[string]interface{}); !ok {
+		return "", fmt.Errorf("TOML requires an object at the document root, got %s", jsonType(data))
+	}
+
+	converted, err := jsonToTOML(data, nil)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(converted); err != nil {
+		return "", fmt.Errorf("failed to encode TOML: %v", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonToTOML prepares a document parsed with json.Number values for the TOML
+// encoder, keeping integers as TOML integers rather than floats. Integers
+// outside the 64-bit range TOML allows are rejected rather than rounded.
+func jsonToTOML(value interface{}, path []string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for _, key := range sortedKeys(v) {
+			c, err := jsonToTOML(v[key], append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			converted[key] = c
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, child := range v {
+			c, err := jsonToTOML(child, append(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = c
+		}
+		return converted, nil
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			n, err := v.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("TOML cannot represent the integer %s at %q: it does not fit in 64 bits", v, formatPointer(path))
+			}
+			return n, nil
+		}
+		return v.Float64()
+	case nil:
+		return nil, fmt.Errorf("TOML cannot represent null at %q", formatPointer(path))
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const sampleTOML = `title = "Example"
+
+[owner]
+name = "Tom"
+dob = 1979-05-27T07:32:00-08:00
+
+[database]
+ports = [8000, 8001]
+enabled = true
+ratio = 0.5
+started = 2020-01-02T08:00:00
+backup = 03:00:00
+
+[[products]]
+name = "Hammer"
+sku = 738594937
+
+[[products]]
+name = "Nail"
+released = 2020-01-02
+`
+
+func TestFromTOML(t *testing.T) {
+	data, err := fromTOML(sampleTOML)
+	if err != nil {
+		t.Fatalf("fromTOML() error = %v", err)
+	}
+	result, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	expected := `{"database":{"backup":"03:00:00","enabled":true,"ports":[8000,8001],"ratio":0.5,"started":"2020-01-02T08:00:00"},` +
+		`"owner":{"dob":"1979-05-27T07:32:00-08:00","name":"Tom"},` +
+		`"products":[{"name":"Hammer","sku":738594937},{"name":"Nail","released":"2020-01-02"}],` +
+		`"title":"Example"}`
+	if string(result) != expected {
+		t.Errorf("fromTOML() = %s, want %s", result, expected)
+	}
+}
+
+func TestTOMLRoundTrip(t *testing.T) {
+	data, err := fromTOML(sampleTOML)
+	if err != nil {
+		t.Fatalf("fromTOML() error = %v", err)
+	}
+	original, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	tomlStr, err := toTOML(string(original), limitOptions{})
+	if err != nil {
+		t.Fatalf("toTOML() error = %v", err)
+	}
+	if !strings.Contains(tomlStr, "[[products]]") {
+		t.Errorf("toTOML() = %s, want an array of tables", tomlStr)
+	}
+	if !strings.Contains(tomlStr, "sku = 738594937\n") {
+		t.Errorf("toTOML() = %s, want integers kept as integers", tomlStr)
+	}
+
+	again, err := fromTOML(tomlStr)
+	if err != nil {
+		t.Fatalf("fromTOML() error = %v (input: %s)", err, tomlStr)
+	}
+	roundTripped, err := json.Marshal(again)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(roundTripped) != string(original) {
+		t.Errorf("TOML round trip = %s, want %s", roundTripped, original)
+	}
+}
+
+func TestToTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "array root", input: `[1, 2]`, wantErr: "object at the document root"},
+		{name: "null value", input: `{"a": {"b": null}}`, wantErr: `null at "/a/b"`},
+		{name: "invalid JSON", input: `{"a": }`, wantErr: "invalid JSON input"},
+		{name: "integer above int64", input: `{"id": 12345678901234567890}`, wantErr: `integer 12345678901234567890 at "/id"`},
+		{name: "integer below int64", input: `{"a": [-9223372036854775809]}`, wantErr: `integer -9223372036854775809 at "/a/0"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := toTOML(tt.input, limitOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("toTOML() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestToTOMLNumbers(t *testing.T) {
+	result, err := toTOML(`{"max": 9223372036854775807, "min": -9223372036854775808, "ratio": 0.25, "exp": 1e2}`, limitOptions{})
+	if err != nil {
+		t.Fatalf("toTOML() error = %v", err)
+	}
+	expected := "exp = 100.0\nmax = 9223372036854775807\nmin = -9223372036854775808\nratio = 0.25"
+	if result != expected {
+		t.Errorf("toTOML() = %q, want %q", result, expected)
+	}
+}
+
+func TestFromTOMLInvalid(t *testing.T) {
+	if _, err := fromTOML(`title = `); err == nil {
+		t.Errorf("fromTOML() expected error for invalid TOML")
+	}
+}
+
+func TestFromTOMLNonFiniteFloats(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "nan", input: "a = nan", wantErr: `float nan at "/a"`},
+		{name: "inf in a table", input: "[server]\nlimit = +inf", wantErr: `float inf at "/server/limit"`},
+		{name: "negative inf in an array", input: "xs = [1.0, -inf]", wantErr: `float -inf at "/xs/1"`},
+		{name: "array of tables", input: "[[t]]\nx = 1.5\n[[t]]\nx = nan", wantErr: `float nan at "/t/1/x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fromTOML(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("fromTOML() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"fromtoml", "a = nan"}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("run() = %d, want 1", code)
+	}
+	if strings.Contains(stderr.String(), "writing output") {
+		t.Errorf("run() stderr = %q, want the conversion to fail before output", stderr.String())
+	}
+}
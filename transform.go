@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// transformOptions holds the document transforms selected on the command line
+type transformOptions struct {
+	rootKey          string
+	forceRoot        bool
+	clampDepth       int // zero when depth clamping is off
+	clampPlaceholder string
+	nullTo           string // JSON default for null values, empty when off
+	emptyPolicy      string
+	emptyType        string
+	trimTrailingNull bool
+	trimAllNulls     bool
+	unicodeForm      string
+	normalizeKeys    bool
+	timestampLayout  string
+	timestampKeys    string
+	coerceBooleans   bool
+	booleanKeys      string
+	resolveIncludes  bool
+	source           string // file the input was read from, if any
+	resolveRefs      bool
+	trimKeys         bool
+	stripKeyPrefix   string // separator ending the prefix, empty when off
+	envsubstKeys     bool
+	overwrite        bool
+	stripControl     bool
+	replaceControl   string
+	controlKeys      bool
+	keyCollision     string
+	jsNumbers        bool
+	pipeline         string
+	addFields        stringList // "pointer=value" assignments
+}
+
+// applyTransforms runs every selected transform over a parsed document
+func applyTransforms(data interface{}, opts transformOptions) (interface{}, error) {
+	if opts.resolveIncludes {
+		var err error
+		if data, err = resolveIncludes(data, opts.source); err != nil {
+			return nil, err
+		}
+	}
+	if opts.resolveRefs {
+		var err error
+		if data, err = resolveRefs(data); err != nil {
+			return nil, err
+		}
+	}
+	if opts.trimKeys {
+		if err := validateKeyCollision(opts.keyCollision); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = trimKeys(data, opts.keyCollision); err != nil {
+			return nil, err
+		}
+	}
+	if opts.stripKeyPrefix != "" {
+		if err := validateKeyCollision(opts.keyCollision); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = stripKeyPrefix(data, opts.stripKeyPrefix, opts.keyCollision); err != nil {
+			return nil, err
+		}
+	}
+	if opts.envsubstKeys {
+		var err error
+		if data, err = expandEnvKeys(data, opts.overwrite); err != nil {
+			return nil, err
+		}
+	}
+	if opts.stripControl || opts.replaceControl != "" {
+		var err error
+		if data, err = stripControl(data, opts.replaceControl, opts.controlKeys, opts.keyCollision); err != nil {
+			return nil, err
+		}
+	}
+	if opts.unicodeForm != "" {
+		form, err := parseUnicodeForm(opts.unicodeForm)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = normalizeUnicode(data, form, opts.normalizeKeys, opts.keyCollision); err != nil {
+			return nil, err
+		}
+	}
+	if opts.timestampLayout != "" {
+		layout, err := parseTimestampLayout(opts.timestampLayout)
+		if err != nil {
+			return nil, err
+		}
+		data = normalizeTimestamps(data, layout, parseKeyList(opts.timestampKeys))
+	}
+	if opts.trimTrailingNull || opts.trimAllNulls {
+		data = trimArrayNulls(data, opts.trimAllNulls)
+	}
+	if opts.nullTo != "" {
+		var replacement interface{}
+		if err := json.Unmarshal([]byte(opts.nullTo), &replacement); err != nil {
+			return nil, fmt.Errorf("invalid --null-to: %v", err)
+		}
+		data = replaceNulls(data, replacement)
+	}
+	if opts.emptyPolicy != "" {
+		var err error
+		if data, err = applyEmptyPolicy(data, opts.emptyPolicy, opts.emptyType); err != nil {
+			return nil, err
+		}
+	}
+	if opts.coerceBooleans {
+		data = coerceBooleans(data, parseKeyList(opts.booleanKeys))
+	}
+	if opts.clampDepth > 0 {
+		var placeholder interface{}
+		if err := json.Unmarshal([]byte(opts.clampPlaceholder), &placeholder); err != nil {
+			return nil, fmt.Errorf("invalid --clamp-placeholder: %v", err)
+		}
+		data = clampDepth(data, opts.clampDepth, placeholder)
+	}
+	if opts.rootKey != "" {
+		data = wrapRoot(data, opts.rootKey, opts.forceRoot)
+	}
+	if len(opts.addFields) > 0 {
+		var err error
+		if data, err = addFields(data, opts.addFields); err != nil {
+			return nil, err
+		}
+	}
+	if opts.jsNumbers {
+		data = jsNumbers(data)
+	}
+	if opts.pipeline != "" {
+		var err error
+		if data, err = runPipeline(data, opts.pipeline); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// wrapRoot nests a value under a single key so that the document root is an
+// object. Values that are already objects are returned unchanged unless force
+// is set.
+func wrapRoot(value interface{}, key string, force bool) interface{} {
+	if _, isObject := value.(map[string]interface{}); isObject && !force {
+		return value
+	}
+	return map[string]interface{}{key: value}
+}
+
+// replaceNulls substitutes replacement for every null value at any depth.
+// The replacement is shared, not copied, as values are never modified in
+// place.
+func replaceNulls(value interface{}, replacement interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return replacement
+	case map[string]interface{}:
+		replaced := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			replaced[key] = replaceNulls(child, replacement)
+		}
+		return replaced
+	case []interface{}:
+		replaced := make([]interface{}, len(v))
+		for i, child := range v {
+			replaced[i] = replaceNulls(child, replacement)
+		}
+		return replaced
+	default:
+		return value
+	}
+}
+
+// emptyValues maps the --empty-type names to the empty value of that type
+var emptyValues = map[string]func() interface{}{
+	"string": func() interface{} { return "" },
+	"array":  func() interface{} { return []interface{}{} },
+	"object": func() interface{} { return map[string]interface{}{} },
+}
+
+// applyEmptyPolicy converts between null and empty values. empty-to-null
+// turns every empty string, array and object into null; null-to-empty
+// turns every null into the empty value of emptyType, since a null does
+// not say which type it stands for.
+func applyEmptyPolicy(value interface{}, policy, emptyType string) (interface{}, error) {
+	switch policy {
+	case "empty-to-null":
+		return emptyToNull(value), nil
+	case "null-to-empty":
+		empty, ok := emptyValues[emptyType]
+		if !ok {
+			return nil, fmt.Errorf("unknown --empty-type %q (want string, array or object)", emptyType)
+		}
+		return replaceNulls(value, empty()), nil
+	}
+	return nil, fmt.Errorf("unknown --empty-policy %q (want empty-to-null or null-to-empty)", policy)
+}
+
+// emptyToNull replaces every empty string, array and object with null.
+// Containers that become all nulls are not empty and are kept.
+func emptyToNull(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		replaced := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			replaced[key] = emptyToNull(child)
+		}
+		return replaced
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		replaced := make([]interface{}, len(v))
+		for i, child := range v {
+			replaced[i] = emptyToNull(child)
+		}
+		return replaced
+	}
+	return value
+}
+
+// trimArrayNulls removes the null elements at the end of every array, or
+// with all set every null element. Interior nulls are otherwise kept, as
+// are null object members.
+func trimArrayNulls(value interface{}, all bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		trimmed := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			trimmed[key] = trimArrayNulls(child, all)
+		}
+		return trimmed
+	case []interface{}:
+		end := len(v)
+		for end > 0 && v[end-1] == nil {
+			end--
+		}
+		trimmed := make([]interface{}, 0, end)
+		for _, child := range v[:end] {
+			if child == nil && all {
+				continue
+			}
+			trimmed = append(trimmed, trimArrayNulls(child, all))
+		}
+		return trimmed
+	default:
+		return value
+	}
+}
+
+// clampDepth keeps at most depth levels of nested objects and arrays,
+// replacing any container below that with placeholder. Scalars never add
+// depth, so documents nested no deeper than depth are returned unchanged.
+func clampDepth(value interface{}, depth int, placeholder interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if depth <= 0 {
+			return placeholder
+		}
+		clamped := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			clamped[key] = clampDepth(child, depth-1, placeholder)
+		}
+		return clamped
+	case []interface{}:
+		if depth <= 0 {
+			return placeholder
+		}
+		clamped := make([]interface{}, len(v))
+		for i, child := range v {
+			clamped[i] = clampDepth(child, depth-1, placeholder)
+		}
+		return clamped
+	default:
+		return value
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestWrapRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		force    bool
+		expected string
+	}{
+		{
+			name:     "array is wrapped",
+			input:    `[1, 2, 3]`,
+			expected: `{"items": [1, 2, 3]}`,
+		},
+		{
+			name:     "scalar is wrapped",
+			input:    `"hello"`,
+			expected: `{"items": "hello"}`,
+		},
+		{
+			name:     "null is wrapped",
+			input:    `null`,
+			expected: `{"items": null}`,
+		},
+		{
+			name:     "object is left alone",
+			input:    `{"key": "value"}`,
+			expected: `{"key": "value"}`,
+		},
+		{
+			name:     "object is wrapped with force",
+			input:    `{"key": "value"}`,
+			force:    true,
+			expected: `{"items": {"key": "value"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			want, err := parseJSON(tt.expected)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			if got := wrapRoot(data, "items", tt.force); !equalJSON(got, want) {
+				t.Errorf("wrapRoot() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestClampDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		depth    int
+		expected string
+	}{
+		{
+			name:     "truncates below the boundary",
+			input:    `{"a": {"b": {"c": 1}}, "d": [1, [2]]}`,
+			depth:    2,
+			expected: `{"a": {"b": "…"}, "d": [1, "…"]}`,
+		},
+		{
+			name:     "containers at the boundary are kept",
+			input:    `{"a": {"b": {"c": 1}}}`,
+			depth:    3,
+			expected: `{"a": {"b": {"c": 1}}}`,
+		},
+		{
+			name:     "shallow document is unchanged",
+			input:    `{"a": 1, "b": [true, null]}`,
+			depth:    5,
+			expected: `{"a": 1, "b": [true, null]}`,
+		},
+		{
+			name:     "empty containers still count as a level",
+			input:    `{"a": {}, "b": []}`,
+			depth:    1,
+			expected: `{"a": "…", "b": "…"}`,
+		},
+		{
+			name:     "zero depth replaces the root container",
+			input:    `[1, 2]`,
+			depth:    0,
+			expected: `"…"`,
+		},
+		{
+			name:     "scalar root never clamps",
+			input:    `"text"`,
+			depth:    0,
+			expected: `"text"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			want, err := parseJSON(tt.expected)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			if got := clampDepth(data, tt.depth, "…"); !equalJSON(got, want) {
+				t.Errorf("clampDepth() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyTransformsClampPlaceholder(t *testing.T) {
+	data, err := parseJSON(`{"a": {"b": 1}, "c": 2}`)
+	if err != nil {
+		t.Fatalf("parseJSON() error = %v", err)
+	}
+
+	result, err := applyTransforms(data, transformOptions{clampDepth: 1, clampPlaceholder: `null`})
+	if err != nil {
+		t.Fatalf("applyTransforms() error = %v", err)
+	}
+	want, _ := parseJSON(`{"a": null, "c": 2}`)
+	if !equalJSON(result, want) {
+		t.Errorf("applyTransforms() = %v, want %v", result, want)
+	}
+
+	if _, err := applyTransforms(data, transformOptions{clampDepth: 1, clampPlaceholder: `…`}); err == nil {
+		t.Errorf("applyTransforms() expected error for a placeholder that is not JSON")
+	}
+}
+
+func TestReplaceNulls(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		nullTo   string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "nested objects and arrays",
+			input:    `{"a": null, "b": [1, null, {"c": null}], "d": {"e": {"f": null}}}`,
+			nullTo:   `0`,
+			expected: `{"a": 0, "b": [1, 0, {"c": 0}], "d": {"e": {"f": 0}}}`,
+		},
+		{
+			name:     "root null",
+			input:    `null`,
+			nullTo:   `"n/a"`,
+			expected: `"n/a"`,
+		},
+		{
+			name:     "container default",
+			input:    `[null, []]`,
+			nullTo:   `{"missing": true}`,
+			expected: `[{"missing": true}, []]`,
+		},
+		{
+			name:     "other values kept",
+			input:    `{"s": "null", "f": false, "z": 0}`,
+			nullTo:   `1`,
+			expected: `{"s": "null", "f": false, "z": 0}`,
+		},
+		{
+			name:    "default not JSON",
+			input:   `null`,
+			nullTo:  `n/a`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			result, err := applyTransforms(data, transformOptions{nullTo: tt.nullTo})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyTransforms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want, _ := parseJSON(tt.expected)
+			if !equalJSON(result, want) {
+				t.Errorf("applyTransforms() = %v, want %v", result, want)
+			}
+		})
+	}
+}
+
+func TestTrimArrayNulls(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		trailing string
+		all      string
+	}{
+		{
+			name:     "trailing and interior nulls",
+			input:    `[1, null, 2, null, null]`,
+			trailing: `[1, null, 2]`,
+			all:      `[1, 2]`,
+		},
+		{
+			name:     "only nulls",
+			input:    `[null, null]`,
+			trailing: `[]`,
+			all:      `[]`,
+		},
+		{
+			name:     "nested arrays",
+			input:    `{"rows": [[1, null], [null, 2, null]], "gone": null}`,
+			trailing: `{"rows": [[1], [null, 2]], "gone": null}`,
+			all:      `{"rows": [[1], [2]], "gone": null}`,
+		},
+		{
+			name:     "no nulls",
+			input:    `[[], {}, 0, ""]`,
+			trailing: `[[], {}, 0, ""]`,
+			all:      `[[], {}, 0, ""]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(tt.input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			trailing, _ := parseJSON(tt.trailing)
+			if got := trimArrayNulls(data, false); !equalJSON(got, trailing) {
+				t.Errorf("trimArrayNulls(all=false) = %v, want %v", got, trailing)
+			}
+			all, _ := parseJSON(tt.all)
+			if got := trimArrayNulls(data, true); !equalJSON(got, all) {
+				t.Errorf("trimArrayNulls(all=true) = %v, want %v", got, all)
+			}
+		})
+	}
+}
+
+func TestApplyEmptyPolicy(t *testing.T) {
+	input := `{"name": "", "tags": [], "meta": {}, "rows": [[], [""], null], "note": null, "n": 0}`
+
+	tests := []struct {
+		name      string
+		policy    string
+		emptyType string
+		expected  string
+		wantErr   bool
+	}{
+		{
+			name:     "empty to null",
+			policy:   "empty-to-null",
+			expected: `{"name": null, "tags": null, "meta": null, "rows": [null, [null], null], "note": null, "n": 0}`,
+		},
+		{
+			name:      "null to empty string",
+			policy:    "null-to-empty",
+			emptyType: "string",
+			expected:  `{"name": "", "tags": [], "meta": {}, "rows": [[], [""], ""], "note": "", "n": 0}`,
+		},
+		{
+			name:      "null to empty array",
+			policy:    "null-to-empty",
+			emptyType: "array",
+			expected:  `{"name": "", "tags": [], "meta": {}, "rows": [[], [""], []], "note": [], "n": 0}`,
+		},
+		{
+			name:      "null to empty object",
+			policy:    "null-to-empty",
+			emptyType: "object",
+			expected:  `{"name": "", "tags": [], "meta": {}, "rows": [[], [""], {}], "note": {}, "n": 0}`,
+		},
+		{name: "null to empty without a type", policy: "null-to-empty", wantErr: true},
+		{name: "unknown policy", policy: "drop", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseJSON(input)
+			if err != nil {
+				t.Fatalf("parseJSON() error = %v", err)
+			}
+			result, err := applyTransforms(data, transformOptions{emptyPolicy: tt.policy, emptyType: tt.emptyType})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyTransforms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want, _ := parseJSON(tt.expected)
+			if !equalJSON(result, want) {
+				t.Errorf("applyTransforms() = %v, want %v", result, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "io"
+
+// capWriter passes at most limit bytes through to w and silently drops the
+// rest, recording how much was dropped. Writes always report success, so
+// that writers layered on top, such as --tee, keep going.
+type capWriter struct {
+	w       io.Writer
+	limit   int
+	written int
+	dropped int
+}
+
+func newCapWriter(w io.Writer, limit int) *capWriter {
+	return &capWriter{w: w, limit: limit}
+}
+
+func (cw *capWriter) Write(p []byte) (int, error) {
+	keep := cw.limit - cw.written
+	if keep > len(p) {
+		keep = len(p)
+	}
+	if keep > 0 {
+		n, err := cw.w.Write(p[:keep])
+		cw.written += n
+		if err != nil {
+			return n, err
+		}
+	}
+	cw.dropped += len(p) - max(keep, 0)
+	return len(p), nil
+}
+
+// truncated reports whether any output was dropped
+func (cw *capWriter) truncated() bool {
+	return cw.dropped > 0
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCapWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		writes   []string
+		expected string
+		dropped  int
+	}{
+		{name: "under the cap", limit: 10, writes: []string{"abc", "de"}, expected: "abcde"},
+		{name: "exactly at the cap", limit: 5, writes: []string{"abcde"}, expected: "abcde"},
+		{name: "cut inside a write", limit: 4, writes: []string{"abc", "def"}, expected: "abcd", dropped: 2},
+		{name: "writes after the cap dropped", limit: 3, writes: []string{"abc", "d", "ef"}, expected: "abc", dropped: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cw := newCapWriter(&buf, tt.limit)
+			for _, s := range tt.writes {
+				n, err := cw.Write([]byte(s))
+				if err != nil || n != len(s) {
+					t.Fatalf("Write(%q) = %d, %v, want %d, nil", s, n, err, len(s))
+				}
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("output = %q, want %q", buf.String(), tt.expected)
+			}
+			if cw.dropped != tt.dropped || cw.truncated() != (tt.dropped > 0) {
+				t.Errorf("dropped = %d, truncated = %v, want %d", cw.dropped, cw.truncated(), tt.dropped)
+			}
+		})
+	}
+}
+
+func TestRunMaxOutput(t *testing.T) {
+	input := `[1, 2, 3, 4, 5, 6, 7, 8, 9, 10]`
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--max-output", "8", "array", input}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if stdout.String() != "[1,2,3,4" {
+		t.Errorf("run() stdout = %q, want the first 8 bytes", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "output truncated at 8 bytes (15 more bytes not shown)") {
+		t.Errorf("run() stderr = %q, want truncation marker", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--max-output", "100", "array", input}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0", code)
+	}
+	if stdout.String() != "[1,2,3,4,5,6,7,8,9,10]\n" || stderr.Len() != 0 {
+		t.Errorf("run() stdout = %q, stderr = %q, want full output and no marker", stdout.String(), stderr.String())
+	}
+}
+
+func TestRunMaxOutputTee(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out.json")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--max-output", "3", "--tee", filename, "array", `[1, 2]`}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if stdout.String() != "[1," || string(content) != "[1,2]\n" {
+		t.Errorf("stdout = %q, tee file = %q, want capped stdout and complete file", stdout.String(), content)
+	}
+}
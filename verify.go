@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// parseJSONNumbers parses JSON like parseJSON but keeps every number as a
+// json.Number holding its exact original text
+func parseJSONNumbers(jsonStr string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(jsonStr)))
+	decoder.UseNumber()
+	var jsonData interface{}
+	if err := decoder.Decode(&jsonData); err != nil {
+		return nil, fmt.Errorf("invalid JSON input: %v", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("invalid JSON input: unexpected data after top-level value")
+	}
+	return jsonData, nil
+}
+
+// verifyEncoding decodes an encoded result back and checks that it holds the
+// same document as expected. Numbers are compared by exact decimal value,
+// so precision lost while encoding is reported rather than hidden.
+func verifyEncoding(encoded string, expected interface{}) error {
+	decoded, err := decodeJSON(encoded)
+	if err != nil {
+		return fmt.Errorf("verification failed: %v", err)
+	}
+	actual, err := parseJSONNumbers(decoded)
+	if err != nil {
+		return fmt.Errorf("verification failed: %v", err)
+	}
+	if path, differs := firstDifference(expected, actual, nil); differs {
+		return fmt.Errorf("verification failed: encoded output differs from the input at %q", path)
+	}
+	return nil
+}
+
+// firstDifference compares two documents parsed with json.Number values and
+// returns the JSON Pointer of the first place they differ. Numbers compare
+// by value, whether json.Number or float64.
+func firstDifference(a, b interface{}, path []string) (string, bool) {
+	switch aVal := a.(type) {
+	case map[string]interface{}:
+		bVal, ok := b.(map[string]interface{})
+		if !ok || len(aVal) != len(bVal) {
+			return formatPointer(path), true
+		}
+		for _, key := range sortedKeys(aVal) {
+			bChild, ok := bVal[key]
+			if !ok {
+				return formatPointer(append(path, key)), true
+			}
+			if p, differs := firstDifference(aVal[key], bChild, append(path, key)); differs {
+				return p, true
+			}
+		}
+		return "", false
+	case []interface{}:
+		bVal, ok := b.([]interface{})
+		if !ok || len(aVal) != len(bVal) {
+			return formatPointer(path), true
+		}
+		for i := range aVal {
+			if p, differs := firstDifference(aVal[i], bVal[i], append(path, strconv.Itoa(i))); differs {
+				return p, true
+			}
+		}
+		return "", false
+	case json.Number, float64:
+		aNum, _ := numberText(a)
+		bNum, ok := numberText(b)
+		if !ok || !sameNumber(aNum, bNum) {
+			return formatPointer(path), true
+		}
+		return "", false
+	default:
+		if a != b {
+			return formatPointer(path), true
+		}
+		return "", false
+	}
+}
+
+// numberText returns a number as a json.Number. Parsed numbers already are
+// one, while values inserted by transforms such as --null-to are float64.
+func numberText(v interface{}) (json.Number, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		return n, true
+	case float64:
+		return json.Number(strconv.FormatFloat(n, 'g', -1, 64)), true
+	}
+	return "", false
+}
+
+// sameNumber reports whether two JSON numbers have the same exact value,
+// so that 1, 1.0 and 1e0 are equal but 12345678901234567890 and
+// 12345678901234567000 are not
+func sameNumber(a, b json.Number) bool {
+	x, okX := new(big.Rat).SetString(string(a))
+	y, okY := new(big.Rat).SetString(string(b))
+	if !okX || !okY {
+		return a == b
+	}
+	return x.Cmp(y) == 0
+}
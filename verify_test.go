@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyEncodedInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		transforms transformOptions
+		wantErr    string
+	}{
+		{
+			name:  "normal document",
+			input: `{"name": "John", "age": 30, "tags": ["a", "b"], "ok": true, "none": null}`,
+		},
+		{
+			name:  "equivalent number spellings",
+			input: `{"a": 1.0, "b": 1e2, "c": -0.50}`,
+		},
+		{
+			name:  "transforms applied to both sides",
+			input: `[1, {"deep": {"deeper": 1}}]`,
+			transforms: transformOptions{
+				rootKey:          "items",
+				clampDepth:       2,
+				clampPlaceholder: `"…"`,
+			},
+		},
+		{
+			name:       "number inserted by --null-to",
+			input:      `{"a": null, "b": [null, 2]}`,
+			transforms: transformOptions{nullTo: "0"},
+		},
+		{
+			name:       "number placeholder from --clamp-depth",
+			input:      `{"a": {"b": 1}, "c": 1.5}`,
+			transforms: transformOptions{clampDepth: 1, clampPlaceholder: "0"},
+		},
+		{
+			name:       "numbers added by --add-field",
+			input:      `{"m": 1}`,
+			transforms: transformOptions{addFields: stringList{"/n=3", "/big=1e21", "/f=0.25"}},
+		},
+		{
+			name:  "bare string input",
+			input: `say "hi"`,
+		},
+		{
+			name:    "precision loss in a large integer",
+			input:   `{"id": 12345678901234567890}`,
+			wantErr: `differs from the input at "/id"`,
+		},
+		{
+			name:    "precision loss in a nested float",
+			input:   `{"values": [1, 0.1000000000000000055511151231257827021181583404541015625001]}`,
+			wantErr: `differs from the input at "/values/1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseEncodeInput(tt.input, limitOptions{}, true)
+			if err != nil {
+				t.Fatalf("parseEncodeInput() error = %v", err)
+			}
+			data, err = applyTransforms(data, tt.transforms)
+			if err != nil {
+				t.Fatalf("applyTransforms() error = %v", err)
+			}
+			encoded, err := encodeValue(data)
+			if err != nil {
+				t.Fatalf("encodeValue() error = %v", err)
+			}
+
+			err = verifyEncodedInput(tt.input, encoded, tt.transforms)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("verifyEncodedInput() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verifyEncodedInput() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyEncodingMismatch(t *testing.T) {
+	expected, err := parseJSONNumbers(`{"a": [1, 2], "b": "x"}`)
+	if err != nil {
+		t.Fatalf("parseJSONNumbers() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr string
+	}{
+		{name: "identical", encoded: `"{\"b\":\"x\",\"a\":[1,2.0]}"`},
+		{name: "changed string", encoded: `"{\"a\":[1,2],\"b\":\"y\"}"`, wantErr: `at "/b"`},
+		{name: "missing element", encoded: `"{\"a\":[1],\"b\":\"x\"}"`, wantErr: `at "/a"`},
+		{name: "missing key", encoded: `"{\"a\":[1,2],\"c\":\"x\"}"`, wantErr: `at "/b"`},
+		{name: "changed number", encoded: `"{\"a\":[1,3],\"b\":\"x\"}"`, wantErr: `at "/a/1"`},
+		{name: "number replaced by a string", encoded: `"{\"a\":[1,\"2\"],\"b\":\"x\"}"`, wantErr: `at "/a/1"`},
+		{name: "not decodable", encoded: `{}`, wantErr: "verification failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyEncoding(tt.encoded, expected)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("verifyEncoding() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verifyEncoding() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
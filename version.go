@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// version is the release of this build, set at link time with
+//
+//	go build -ldflags "-X main.version=1.2.0"
+//
+// Builds installed with "go install ...@version" report the module version
+// instead.
+var version = "dev"
+
+// currentVersion returns the version of the running binary
+func currentVersion() string {
+	if version == "dev" {
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			return strings.TrimPrefix(info.Main.Version, "v")
+		}
+	}
+	return version
+}
+
+// writeVersion prints the tool and Go versions
+func writeVersion(w io.Writer) {
+	fmt.Fprintf(w, "jsonencoder version %s\n", currentVersion())
+	fmt.Fprintf(w, "go version %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// pseudoVersion matches the timestamp and commit that the go command
+// stamps into untagged builds, e.g. 0.0.0-20240102150405-abcdef123456
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+
+// checkMinVersion fails when the running binary is older than required.
+// Development and untagged builds satisfy every requirement.
+func checkMinVersion(required, current string) error {
+	want, err := parseVersion(required)
+	if err != nil {
+		return fmt.Errorf("invalid minVersion %q: %v", required, err)
+	}
+	if current == "dev" || pseudoVersion.MatchString(current) {
+		return nil
+	}
+	have, err := parseVersion(current)
+	if err != nil {
+		// Versions that are not numeric, such as pseudo-versions, are not checked
+		return nil
+	}
+	for i := range want {
+		if have[i] != want[i] {
+			if have[i] < want[i] {
+				return fmt.Errorf("requires jsonencoder %s or newer, this is %s", required, current)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseVersion splits a MAJOR[.MINOR[.PATCH]] version, with an optional
+// leading "v" and ignoring any pre-release or build suffix
+func parseVersion(v string) ([3]int, error) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) > 3 {
+		return parts, fmt.Errorf("too many components")
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("%q is not a version number", field)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
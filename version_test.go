@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCheckMinVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		required string
+		current  string
+		wantErr  bool
+	}{
+		{name: "same version", required: "1.2.0", current: "1.2.0"},
+		{name: "newer patch", required: "1.2.0", current: "1.2.3"},
+		{name: "newer major", required: "1.9", current: "2.0.0"},
+		{name: "leading v", required: "v1.2", current: "1.2.0"},
+		{name: "older minor", required: "1.3.0", current: "1.2.9", wantErr: true},
+		{name: "older major", required: "2", current: "1.9.9", wantErr: true},
+		{name: "pre-release suffix ignored", required: "1.2.0", current: "1.2.0-rc1"},
+		{name: "development build", required: "99.0.0", current: "dev"},
+		{name: "untagged build", required: "99.0.0", current: "0.0.0-20240102150405-abcdef123456+dirty"},
+		{name: "invalid requirement", required: "latest", current: "1.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMinVersion(tt.required, tt.current)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkMinVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunVersion(t *testing.T) {
+	for _, args := range [][]string{{"version"}, {"--version"}} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, nil, &stdout, &stderr); code != 0 {
+			t.Fatalf("run(%v) = %d, want 0", args, code)
+		}
+		output := stdout.String()
+		if !strings.HasPrefix(output, "jsonencoder version "+currentVersion()+"\n") {
+			t.Errorf("run(%v) stdout = %q, want tool version", args, output)
+		}
+		if !strings.Contains(output, "go version "+runtime.Version()) {
+			t.Errorf("run(%v) stdout = %q, want Go version", args, output)
+		}
+	}
+}
+
+func TestConfigMinVersion(t *testing.T) {
+	saved := version
+	version = "1.2.0"
+	defer func() { version = saved }()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "satisfied", content: `{"minVersion": "1.1", "first": 1}`},
+		{name: "too new", content: `{"minVersion": "1.3.0", "first": 1}`, wantErr: true},
+		{name: "too new with unknown options", content: `{"minVersion": "2.0.0", "future-option": true}`, wantErr: true},
+		{name: "not a string", content: `{"minVersion": 1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts options
+			var stderr bytes.Buffer
+			config := writeConfig(t, "config.json", tt.content)
+			_, _, err := parseArgs([]string{"--config", config, "encode", "{}"}, &opts, &stderr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && strings.Contains(tt.content, "2.0.0") && !strings.Contains(err.Error(), "requires jsonencoder 2.0.0") {
+				t.Errorf("parseArgs() error = %v, want version error", err)
+			}
+		})
+	}
+}
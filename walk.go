@@ -0,0 +1,42 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+// walkJSON calls fn for a value and then for every value nested inside it,
+// depth first. Object keys are visited in sorted order so that results built
+// from the walk are deterministic. path holds the reference tokens leading
+// to the current value and must not be retained by fn.
+func walkJSON(value interface{}, path []string, fn func(path []string, value interface{}) error) error {
+	if err := fn(path, value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			if err := walkJSON(v[key], append(path, key), fn); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if err := walkJSON(child, append(path, strconv.Itoa(i)), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of an object in ascending order
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
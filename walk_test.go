@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalkJSON(t *testing.T) {
+	data, err := parseJSON(`{"b": [1, {"c": null}], "a": "x"}`)
+	if err != nil {
+		t.Fatalf("parseJSON() error = %v", err)
+	}
+
+	var visited []string
+	err = walkJSON(data, nil, func(path []string, value interface{}) error {
+		visited = append(visited, formatPointer(path))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkJSON() error = %v", err)
+	}
+
+	expected := []string{"", "/a", "/b", "/b/0", "/b/1", "/b/1/c"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("walkJSON() visited %v, want %v", visited, expected)
+	}
+}
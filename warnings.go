@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"io"
+)
+
+// exitWarnings is the exit code used with --fail-on-warnings when a command
+// succeeded but printed warnings
+const exitWarnings = 4
+
+// warningPrefix starts every warning line written to stderr
+const warningPrefix = "Warning: "
+
+// warningCounter passes writes through to stderr, counting the warnings.
+// Each warning is written as a single line starting with warningPrefix.
+type warningCounter struct {
+	io.Writer
+	count int
+}
+
+func (w *warningCounter) Write(p []byte) (int, error) {
+	if bytes.HasPrefix(p, []byte(warningPrefix)) {
+		w.count++
+	}
+	return w.Writer.Write(p)
+}
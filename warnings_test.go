@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunFailOnWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		warning  string
+	}{
+		{
+			name:     "secret warning",
+			args:     []string{"--fail-on-warnings", "--scan-secrets", "encode", `{"password": "x"}`},
+			exitCode: exitWarnings,
+			warning:  "possible secret",
+		},
+		{
+			name:     "skipped record",
+			args:     []string{"--fail-on-warnings", "--skip-invalid", "fromndjson", "1\nx"},
+			exitCode: exitWarnings,
+			warning:  "skipping invalid line 2",
+		},
+		{
+			name:     "truncated output",
+			args:     []string{"--fail-on-warnings", "--max-output", "2", "array", `[1, 2]`},
+			exitCode: exitWarnings,
+			warning:  "output truncated",
+		},
+		{
+			name:    "warning without the flag",
+			args:    []string{"--scan-secrets", "encode", `{"password": "x"}`},
+			warning: "possible secret",
+		},
+		{
+			name: "no warnings",
+			args: []string{"--fail-on-warnings", "--scan-secrets", "encode", `{"name": "x"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, nil, &stdout, &stderr); code != tt.exitCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.exitCode, stderr.String())
+			}
+			if tt.warning == "" && stderr.Len() != 0 {
+				t.Errorf("run() stderr = %q, want nothing", stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.warning) {
+				t.Errorf("run() stderr = %q, want the warning %q still printed", stderr.String(), tt.warning)
+			}
+			if stdout.Len() == 0 {
+				t.Errorf("run() stdout is empty, want the result still written")
+			}
+		})
+	}
+}
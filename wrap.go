@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"io"
+)
+
+// lineWrapper is a writer that surrounds every line written through it with
+// a prefix and a suffix, e.g. "data: " for server-sent events. The suffix
+// is placed before the line's newline.
+type lineWrapper struct {
+	w       io.Writer
+	prefix  []byte
+	suffix  []byte
+	midLine bool
+}
+
+func newLineWrapper(w io.Writer, prefix, suffix string) *lineWrapper {
+	return &lineWrapper{w: w, prefix: []byte(prefix), suffix: []byte(suffix)}
+}
+
+func (lw *lineWrapper) Write(p []byte) (int, error) {
+	var buf bytes.Buffer
+	for rest := p; len(rest) > 0; {
+		if !lw.midLine {
+			buf.Write(lw.prefix)
+			lw.midLine = true
+		}
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			buf.Write(rest)
+			break
+		}
+		buf.Write(rest[:i])
+		buf.Write(lw.suffix)
+		buf.WriteByte('\n')
+		lw.midLine = false
+		rest = rest[i+1:]
+	}
+
+	if _, err := lw.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
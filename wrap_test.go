@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLineWrapper(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		suffix   string
+		writes   []string
+		expected string
+	}{
+		{
+			name:     "prefix only",
+			prefix:   "data: ",
+			writes:   []string{"{\"key\":\"value\"}\n"},
+			expected: "data: {\"key\":\"value\"}\n",
+		},
+		{
+			name:     "prefix and suffix",
+			prefix:   "const config = ",
+			suffix:   ";",
+			writes:   []string{"\"{}\"\n"},
+			expected: "const config = \"{}\";\n",
+		},
+		{
+			name:     "every line of a multi-line result",
+			prefix:   "> ",
+			suffix:   " <",
+			writes:   []string{"[\n  1\n]\n"},
+			expected: "> [ <\n>   1 <\n> ] <\n",
+		},
+		{
+			name:     "lines split across writes",
+			prefix:   "[",
+			suffix:   "]",
+			writes:   []string{"ab", "c\nd", "e\n"},
+			expected: "[abc]\n[de]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newLineWrapper(&buf, tt.prefix, tt.suffix)
+			for _, s := range tt.writes {
+				n, err := w.Write([]byte(s))
+				if err != nil || n != len(s) {
+					t.Fatalf("Write() = %d, %v, want %d, nil", n, err, len(s))
+				}
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("output = %q, want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestLineWrapperAroundEncodedOutput(t *testing.T) {
+	result, err := encodeJSON(`{"key": "value"}`)
+	if err != nil {
+		t.Fatalf("encodeJSON() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeOutput(newLineWrapper(&buf, "data: ", ""), result); err != nil {
+		t.Fatalf("writeOutput() error = %v", err)
+	}
+
+	expected := "data: \"{\\\"key\\\":\\\"value\\\"}\"\n"
+	if buf.String() != expected {
+		t.Errorf("output = %q, want %q", buf.String(), expected)
+	}
+}
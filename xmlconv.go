@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// The XML mapping used by fromxml and toxml:
+//
+//	<root a="1">text<b>x</b><b>y</b><c/></root>
+//	{"root": {"@a": "1", "#text": "text", "b": ["x", "y"], "c": null}}
+//
+// Attributes become "@name" keys and character data "#text". An element
+// with neither attributes nor children collapses to its text (or null when
+// empty), and repeated sibling elements become an array. All values read
+// from XML are strings. Namespace prefixes are kept as part of the name.
+
+// fromXML converts an XML document into its JSON representation
+func fromXML(xmlStr string) (interface{}, error) {
+	decoder := xml.NewDecoder(strings.NewReader(xmlStr))
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return nil, fmt.Errorf("invalid XML input: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML input: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := readXMLElement(decoder, start)
+			if err != nil {
+				return nil, fmt.Errorf("invalid XML input: %v", err)
+			}
+			return map[string]interface{}{xmlName(start.Name): value}, nil
+		}
+	}
+}
+
+// readXMLElement converts the content of an element whose start tag has
+// already been read, consuming tokens up to its end tag
+func readXMLElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	element := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		element["@"+xmlName(attr.Name)] = attr.Value
+	}
+
+	var text strings.Builder
+	hasChildren := false
+	repeated := make(map[string]bool)
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				err = fmt.Errorf("element <%s> is not closed", xmlName(start.Name))
+			}
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			hasChildren = true
+			child, err := readXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			name := xmlName(t.Name)
+			if existing, seen := element[name]; !seen {
+				element[name] = child
+			} else if repeated[name] {
+				element[name] = append(existing.([]interface{}), child)
+			} else {
+				element[name] = []interface{}{existing, child}
+				repeated[name] = true
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if xmlName(t.Name) != xmlName(start.Name) {
+				return nil, fmt.Errorf("element <%s> closed by </%s>", xmlName(start.Name), xmlName(t.Name))
+			}
+			return finishXMLElement(element, strings.TrimSpace(text.String()), hasChildren), nil
+		}
+	}
+}
+
+func finishXMLElement(element map[string]interface{}, text string, hasChildren bool) interface{} {
+	if len(element) == 0 && !hasChildren {
+		if text == "" {
+			return nil
+		}
+		return text
+	}
+	if text != "" {
+		element["#text"] = text
+	}
+	return element
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+// toXML converts a JSON document using the same mapping as fromXML. The
+// root must be an object with exactly one key, naming the root element.
+func toXML(jsonStr string, limits limitOptions) (string, error) {
+	data, err := parseJSONNumbers(jsonStr)
+	if err != nil {
+		return "", err
+	}
+	if err := checkLimits(data, limits); err != nil {
+		return "", err
+	}
+	root, ok := data.(map[string]interface{})
+	if !ok || len(root) != 1 {
+		return "", fmt.Errorf("XML requires an object with exactly one key (the root element) at the document root")
+	}
+
+	var buf bytes.Buffer
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	for name, value := range root {
+		if err := writeXMLElement(encoder, name, value); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", fmt.Errorf("failed to encode XML: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// writeXMLElement writes one element, or one element per item for arrays
+func writeXMLElement(encoder *xml.Encoder, name string, value interface{}) error {
+	if !isXMLName(name) {
+		return fmt.Errorf("%q is not a valid XML element name", name)
+	}
+
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if _, nested := item.([]interface{}); nested {
+				return fmt.Errorf("XML cannot represent nested arrays in %q", name)
+			}
+			if err := writeXMLElement(encoder, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	var text string
+	var children []string
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			switch {
+			case key == "#text":
+				s, err := xmlText(v[key], name)
+				if err != nil {
+					return err
+				}
+				text = s
+			case strings.HasPrefix(key, "@"):
+				attrName := key[1:]
+				if !isXMLName(attrName) {
+					return fmt.Errorf("%q is not a valid XML attribute name", attrName)
+				}
+				s, err := xmlText(v[key], name)
+				if err != nil {
+					return err
+				}
+				start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attrName}, Value: s})
+			default:
+				children = append(children, key)
+			}
+		}
+	default:
+		s, err := xmlText(value, name)
+		if err != nil {
+			return err
+		}
+		text = s
+	}
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if text != "" {
+		if err := encoder.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		for _, key := range children {
+			if err := writeXMLElement(encoder, key, object[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// xmlText renders a scalar as XML character data
+func xmlText(value interface{}, element string) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		if v {
+			return "true", nil
+		}
+		return "false", nil
+	default:
+		return "", fmt.Errorf("XML text or attribute in %q must be a scalar, got %s", element, jsonType(value))
+	}
+}
+
+// isXMLName reports whether s can be used as an XML element or attribute
+// name, optionally with a namespace prefix
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if unicode.IsLetter(r) || r == '_' || r == ':' {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+			continue
+		}
+		return false
+	}
+	return true
+}
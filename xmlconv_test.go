@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFromXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "text element",
+			input:    `<name>Tom</name>`,
+			expected: `{"name":"Tom"}`,
+		},
+		{
+			name:     "empty element",
+			input:    `<?xml version="1.0"?><empty/>`,
+			expected: `{"empty":null}`,
+		},
+		{
+			name:     "attributes and text",
+			input:    `<price currency="EUR" exact="true">9.50</price>`,
+			expected: `{"price":{"#text":"9.50","@currency":"EUR","@exact":"true"}}`,
+		},
+		{
+			name:     "nested elements",
+			input:    "<config>\n  <db><host>localhost</host><port>5432</port></db>\n</config>",
+			expected: `{"config":{"db":{"host":"localhost","port":"5432"}}}`,
+		},
+		{
+			name:     "repeated elements become arrays",
+			input:    `<list><item>a</item><other/><item id="2">b</item><item>c</item></list>`,
+			expected: `{"list":{"item":["a",{"#text":"b","@id":"2"},"c"],"other":null}}`,
+		},
+		{
+			name:     "namespace prefixes kept",
+			input:    `<ns:a xmlns:ns="urn:x"><ns:b>1</ns:b></ns:a>`,
+			expected: `{"ns:a":{"@xmlns:ns":"urn:x","ns:b":"1"}}`,
+		},
+		{
+			name:    "mismatched end tag",
+			input:   `<a><b></a></b>`,
+			wantErr: true,
+		},
+		{
+			name:    "unclosed element",
+			input:   `<a><b></b>`,
+			wantErr: true,
+		},
+		{
+			name:    "no root element",
+			input:   `<!-- nothing -->`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := fromXML(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fromXML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			result, err := json.Marshal(data)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("fromXML() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "attributes and text",
+			input:    `{"price":{"@currency":"EUR","@exact":true,"#text":9.50}}`,
+			expected: `<price currency="EUR" exact="true">9.50</price>`,
+		},
+		{
+			name:     "arrays repeat the element",
+			input:    `{"list":{"item":["a",{"@id":2,"#text":"b"}],"other":null}}`,
+			expected: "<list>\n  <item>a</item>\n  <item id=\"2\">b</item>\n  <other></other>\n</list>",
+		},
+		{
+			name:     "text is escaped",
+			input:    `{"a":"x < y & z"}`,
+			expected: `<a>x &lt; y &amp; z</a>`,
+		},
+		{
+			name:    "multiple root keys",
+			input:   `{"a":1,"b":2}`,
+			wantErr: true,
+		},
+		{
+			name:    "root not an object",
+			input:   `["a"]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid element name",
+			input:   `{"a":{"1st":"x"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "object attribute",
+			input:   `{"a":{"@b":{"c":1}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "nested arrays",
+			input:   `{"a":{"b":[[1]]}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := toXML(tt.input, limitOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toXML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("toXML() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	original := `<library><book id="1"><title>Go</title></book><book id="2"><title>XML</title><note/></book></library>`
+
+	data, err := fromXML(original)
+	if err != nil {
+		t.Fatalf("fromXML() error = %v", err)
+	}
+	first, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	xmlStr, err := toXML(string(first), limitOptions{})
+	if err != nil {
+		t.Fatalf("toXML() error = %v", err)
+	}
+	if !strings.Contains(xmlStr, `<book id="2">`) {
+		t.Errorf("toXML() = %q, want repeated book elements", xmlStr)
+	}
+
+	data, err = fromXML(xmlStr)
+	if err != nil {
+		t.Fatalf("fromXML() error = %v", err)
+	}
+	second, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("round trip = %s, want %s", second, first)
+	}
+}